@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var alerts Alerts
+
+func init() {
+	gateFlags.Var(&alerts, "alerts", "fail if any alert in the rules `file` holds (may be repeated; see the README for the format)")
+}
+
+// An Alert is a named condition on the total
+// or, if Split is set, on one bucket of a -split of the total.
+type Alert struct {
+	Name string
+	Condition
+	Split, Bucket string
+}
+
+func (a Alert) String() string {
+	s := a.Condition.String()
+	if a.Split != "" {
+		s += " in " + a.Split + "=" + a.Bucket
+	}
+	return s
+}
+
+// ParseAlerts parses a rules file, a subset of YAML
+// with a line of the form
+//
+//	name: metric op value [in split=bucket]
+//
+// for each alert, where the condition is as for -fail-if.
+// Either side may be quoted and # starts a comment.
+func ParseAlerts(name, src string) ([]Alert, error) {
+	var as []Alert
+	for i, line := range strings.Split(src, "\n") {
+		errorf := func(format string, args ...any) error {
+			return fmt.Errorf("%s:%d: %s", name, i+1, fmt.Sprintf(format, args...))
+		}
+
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" || line == "---" {
+			continue
+		}
+		key, val, ok := cutYAMLKey(line)
+		if !ok {
+			return nil, errorf("expected name: condition")
+		}
+		k, err := yamlString(key)
+		if err != nil {
+			return nil, errorf("%v", err)
+		}
+		v, err := yamlString(val)
+		if err != nil {
+			return nil, errorf("%s: %v", k, err)
+		}
+		a := Alert{Name: k}
+		cond, scope, scoped := strings.Cut(v, " in ")
+		if scoped {
+			a.Split, a.Bucket, ok = strings.Cut(strings.TrimSpace(scope), "=")
+			if !ok || Splits[a.Split] == nil || a.Bucket == "" {
+				return nil, errorf("%s: %q is not split=bucket (split one of %s)", k, scope, strings.Join(SplitNames, ", "))
+			}
+		}
+		if a.Condition, err = ParseCondition(cond); err != nil {
+			return nil, errorf("%s: %v", k, err)
+		}
+		as = append(as, a)
+	}
+	return as, nil
+}
+
+// cutYAMLKey splits line at the colon ending its key,
+// which may be quoted.
+func cutYAMLKey(line string) (key, val string, ok bool) {
+	i := 0
+	if q := line[0]; q == '"' || q == '\'' {
+		end := strings.IndexByte(line[1:], q)
+		if end < 0 {
+			return "", "", false
+		}
+		i = end + 2
+	}
+	j := strings.Index(line[i:], ": ")
+	if j < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(line[:i+j]), strings.TrimSpace(line[i+j+2:]), true
+}
+
+// yamlString is the plain or quoted string s.
+func yamlString(s string) (string, error) {
+	switch {
+	case s == "":
+		return "", fmt.Errorf("missing value")
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("unterminated string")
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	return s, nil
+}
+
+// Alerts is a flag.Value collecting the alerts of repeated rules files.
+type Alerts struct {
+	files  []string
+	alerts []Alert
+}
+
+func (as *Alerts) String() string {
+	return strings.Join(as.files, ",")
+}
+
+// Set reads and parses the rules file name.
+func (as *Alerts) Set(name string) error {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	a, err := ParseAlerts(name, string(bs))
+	if err != nil {
+		return err
+	}
+	as.files = append(as.files, name)
+	as.alerts = append(as.alerts, a...)
+	return nil
+}
+
+// NeedSplits returns an error if an alert is on a split not given to -split,
+// so the run fails before counting rather than after.
+func (as *Alerts) NeedSplits() error {
+	for _, a := range as.alerts {
+		if a.Split == "" {
+			continue
+		}
+		found := false
+		for _, s := range splitBy {
+			found = found || s == a.Split
+		}
+		if !found {
+			return fmt.Errorf("alert %q needs -split %s", a.Name, a.Split)
+		}
+	}
+	return nil
+}
+
+// Failed returns a description of each alert that holds for total.
+// An alert on a split that total was not counted with is an error.
+func (as *Alerts) Failed(total *Count) ([]string, error) {
+	var failed []string
+	for _, a := range as.alerts {
+		c := total
+		if a.Split != "" {
+			buckets, ok := total.Splits[a.Split]
+			if !ok {
+				return nil, fmt.Errorf("alert %q needs -split %s", a.Name, a.Split)
+			}
+			// an empty bucket is not recorded
+			if c = buckets[a.Bucket]; c == nil {
+				c = New(a.Bucket)
+			}
+		}
+		m := c.Metrics()
+		if a.Holds(m) {
+			failed = append(failed, fmt.Sprintf("%s: %s (%s = %s)", a.Name, a, a.Metric, formatMetric(m[a.Metric])))
+		}
+	}
+	return failed, nil
+}
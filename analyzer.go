@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+	"golang.org/x/tools/go/analysis"
+)
+
+var vetFix = vetFlags.Bool("fix", false, "apply the suggested fixes to the files instead of reporting them")
+
+// Vet runs the vet command: structlit.Analyzer on the packages,
+// writing each diagnostic or, with -fix, applying the suggested fixes.
+// It loads the packages as the other commands do, with their dependencies,
+// rather than through singlechecker, whose load leaves the dependencies without types.
+func Vet(ctx context.Context, w io.Writer, args []string) error {
+	args, err := Patterns(ctx, args)
+	if err != nil {
+		return err
+	}
+	ps, skipped, err := GetPackages(ctx, "", args)
+	if err != nil {
+		return err
+	}
+	LogSkipped(skipped)
+
+	var diags []string
+	edits := map[string][]Edit{}
+	for _, p := range ps {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		pass := &analysis.Pass{
+			Analyzer:  structlit.Analyzer,
+			Fset:      p.Fset,
+			Files:     p.Syntax,
+			Pkg:       p.Types,
+			TypesInfo: p.TypesInfo,
+			Report: func(d analysis.Diagnostic) {
+				diags = append(diags, fmt.Sprintf("%s: %s", p.Fset.Position(d.Pos), d.Message))
+				for _, fix := range d.SuggestedFixes {
+					for _, e := range fix.TextEdits {
+						pos := p.Fset.Position(e.Pos)
+						edits[pos.Filename] = append(edits[pos.Filename], Edit{pos.Offset, p.Fset.Position(e.End).Offset, string(e.NewText)})
+					}
+				}
+			},
+		}
+		if _, err := structlit.Analyzer.Run(pass); err != nil {
+			return fmt.Errorf("%s: %w", p.ID, err)
+		}
+	}
+
+	if !*vetFix {
+		// with -test a file is in the package and its test variant
+		sort.Strings(diags)
+		n := 0
+		for i, d := range diags {
+			if i > 0 && d == diags[i-1] {
+				continue
+			}
+			n++
+			if _, err := fmt.Fprintln(w, d); err != nil {
+				return err
+			}
+		}
+		if n > 0 {
+			return fmt.Errorf("vet: %d diagnostics", n)
+		}
+		return nil
+	}
+	var files []string
+	for f := range edits {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		src, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(f, []byte(ApplyEdits(string(src), edits[f])), fi.Mode().Perm()); err != nil {
+			return err
+		}
+		log.Printf("fixed %s", RelPath(f))
+	}
+	return nil
+}
+
+// IsVetTool reports whether the arguments are from go vet -vettool,
+// which asks for the flags or the version or runs on a config file
+// given last after any analyzer flags.
+func IsVetTool(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if len(args) == 1 && (args[0] == "-flags" || strings.HasPrefix(args[0], "-V=")) {
+		return true
+	}
+	return strings.HasSuffix(args[len(args)-1], ".cfg")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var outFormat = NewEnum(countFlags, "out", "report", "write the report, for gh-annotations a GitHub Actions notice at each exact match instead, or, for document, one JSON document with the tool version, the flags, the report, and every KV pair", "report", "gh-annotations", "document")
+
+// annotations reports whether -out asks for GitHub Actions annotations.
+func annotations() bool {
+	return outFormat.Value == "gh-annotations"
+}
+
+// WriteAnnotation writes a GitHub Actions workflow command
+// annotating s if it is an exact match.
+func WriteAnnotation(w io.Writer, s *Site) error {
+	if s.Match == nil || !s.Match.Identical {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "::notice file=%s,line=%d,col=%d,title=%s::%s\n",
+		escapeProperty(filepath.ToSlash(RelPath(s.Pos.Filename))), s.Pos.Line, s.Pos.Column,
+		escapeProperty("keyed struct literal"),
+		escapeData(fmt.Sprintf("the value %s matches the key %s, so a shorthand would apply", s.Value, s.Key)))
+	return err
+}
+
+// RelPath returns name relative to the working directory
+// if it is inside it and otherwise name.
+func RelPath(name string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return name
+	}
+	if rel, err := filepath.Rel(wd, name); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return name
+}
+
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var serveAPI = serveFlags.Bool("api", false, "also serve a JSON API over HTTP: GET /packages, GET /packages/{id}/counts, POST /analyze, and POST /analyze/stream, which sends the count of each package as newline-delimited JSON")
+
+// api adds the JSON API to mux.
+func (d *dashboard) api(mux *http.ServeMux) {
+	mux.HandleFunc("/packages", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "GET only", http.StatusMethodNotAllowed)
+			return
+		}
+		ids := []string{}
+		for _, c := range d.pkgs {
+			ids = append(ids, c.ID)
+		}
+		writeJSON(w, ids)
+	})
+	// package IDs contain slashes so the ID is everything between
+	mux.HandleFunc("/packages/", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/packages/"), "/counts")
+		if !ok || r.Method != http.MethodGet {
+			http.NotFound(w, r)
+			return
+		}
+		for _, c := range d.pkgs {
+			if c.ID == id {
+				writeJSON(w, c)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+
+	// loading is the expensive part and uses the go command,
+	// so only run one analysis at a time
+	var mu sync.Mutex
+	mux.HandleFunc("/analyze", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		var req analyzeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		rep, err := analyze(r.Context(), req.Dir, req.Patterns, nil)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			if err := json.NewEncoder(w).Encode(map[string]any{"error": err.Error()}); err != nil {
+				log.Println(err)
+			}
+			return
+		}
+		rep.Meta = NewMeta()
+		writeJSON(w, rep)
+	})
+	// the same as /analyze but as newline-delimited JSON,
+	// a line for each package as it is done and none for the total
+	mux.HandleFunc("/analyze/stream", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		var req analyzeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		if err := enc.Encode(metaRecord{NewMeta()}); err != nil {
+			log.Println(err)
+			return
+		}
+		flusher, _ := w.(http.Flusher)
+		// the error writing to the client, which there is no point telling
+		var werr error
+		_, err := analyze(r.Context(), req.Dir, req.Patterns, func(c *Count) error {
+			if werr = enc.Encode(c); werr != nil {
+				return werr
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return nil
+		})
+		switch {
+		case werr != nil:
+			log.Println(werr)
+		case err != nil:
+			if err := enc.Encode(map[string]string{"error": err.Error()}); err != nil {
+				log.Println(err)
+			}
+		}
+	})
+}
+
+// analyzeRequest is the body of POST /analyze and /analyze/stream.
+type analyzeRequest struct {
+	Patterns []string `json:"patterns"`
+	// Dir is relative to the working directory of the server.
+	Dir string `json:"dir"`
+}
+
+// analyze counts the packages matching patterns in dir,
+// calling each, if not nil, with the count of each package as it is done
+// and stopping at the first error it returns.
+func analyze(ctx context.Context, dir string, patterns []string, each func(*Count) error) (*Report, error) {
+	ps, skipped, err := GetPackages(ctx, dir, patterns)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{Total: New("<total>"), Skipped: skipped}
+	for _, p := range ps {
+		c := CountPackage(ctx, p)
+		r.Packages = append(r.Packages, c)
+		r.Total.Add(c)
+		if each != nil {
+			if err := each(c); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return r, ctx.Err()
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(v); err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+	"golang.org/x/tools/go/packages"
+)
+
+// CountAssignments tallies in c.Assignments the assignments in f
+// of an identifier to a field, as in x.Field = field,
+// to compare how often pairs match outside of literals.
+func CountAssignments(c *Count, p *packages.Package, f *ast.File) {
+	skipped := Skipped(f)
+	ast.Inspect(f, func(n ast.Node) bool {
+		a, ok := n.(*ast.AssignStmt)
+		if !ok || a.Tok != token.ASSIGN || len(a.Lhs) != len(a.Rhs) || skipped(a.Pos()) {
+			return true
+		}
+		for i, lhs := range a.Lhs {
+			sel, ok := lhs.(*ast.SelectorExpr)
+			if !ok {
+				continue
+			}
+			if s := p.TypesInfo.Selections[sel]; s == nil || s.Kind() != types.FieldVal || !CountedType(s.Recv()) {
+				continue
+			}
+			id, ok := a.Rhs[i].(*ast.Ident)
+			if !ok {
+				continue
+			}
+			m := structlit.MatchOf(&ast.KeyValueExpr{Key: sel.Sel, Value: id}, foldMatcher, p.TypesInfo)
+			c.Assignments.Count(m.Identical, m.Partial)
+		}
+		return true
+	})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+var baselineFile = countFlags.String("baseline", "", "annotate each package and the total with how it changed since the report in `file`, written with -json")
+
+// baseline is the report read from -baseline, if any.
+var baseline *Baseline
+
+// baselineMetrics are the metrics whose change is written in the text report.
+// The JSON report has every metric that changed.
+var baselineMetrics = []string{"literals", "kv", "exact", "partial", "no_match", "exact_ratio"}
+
+// Baseline is a previous report to compare the counts of this run to.
+type Baseline struct {
+	name string
+	byID map[string]*Count
+}
+
+// LoadBaseline reads the report named by -baseline
+// or returns nil if there is none.
+func LoadBaseline() (*Baseline, error) {
+	if *baselineFile == "" {
+		return nil, nil
+	}
+	r, err := ReadReport(*baselineFile)
+	if err != nil {
+		return nil, err
+	}
+	b := &Baseline{name: *baselineFile, byID: map[string]*Count{}}
+	for _, c := range append(append(r.Packages, r.Modules...), r.Total) {
+		b.byID[c.ID] = c
+	}
+	return b, nil
+}
+
+// Delta is how a count changed since the baseline.
+type Delta struct {
+	// Added is set if the count is not in the baseline.
+	Added bool `json:"added,omitempty"`
+	// Metrics are the changes of the metrics that differ.
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+}
+
+// Delta returns how c changed since the baseline.
+func (b *Baseline) Delta(c *Count) *Delta {
+	o, ok := b.byID[c.ID]
+	if !ok {
+		return &Delta{Added: true}
+	}
+	d := &Delta{}
+	om, nm := o.Metrics(), c.Metrics()
+	for name, v := range nm {
+		if v != om[name] {
+			if d.Metrics == nil {
+				d.Metrics = map[string]float64{}
+			}
+			d.Metrics[name] = v - om[name]
+		}
+	}
+	return d
+}
+
+// Removed returns the sorted IDs in the baseline that are not in ids.
+func (b *Baseline) Removed(ids map[string]bool) []string {
+	var out []string
+	for id := range b.byID {
+		if !ids[id] {
+			out = append(out, id)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// BaselineReport is the comparison of a report to its baseline.
+type BaselineReport struct {
+	File string `json:"file"`
+	// Deltas are by the ID of each package, module, and the total.
+	Deltas map[string]*Delta `json:"deltas"`
+	// Removed are the IDs in the baseline that are not in the report.
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Compare returns the deltas of cs against the baseline and what was removed since.
+func (b *Baseline) Compare(cs []*Count) *BaselineReport {
+	r := &BaselineReport{File: b.name, Deltas: map[string]*Delta{}}
+	ids := map[string]bool{}
+	for _, c := range cs {
+		ids[c.ID] = true
+		r.Deltas[c.ID] = b.Delta(c)
+	}
+	r.Removed = b.Removed(ids)
+	return r
+}
+
+// FormatDelta is the line of the text report describing d.
+func FormatDelta(d *Delta) string {
+	if d.Added {
+		return "\tsince baseline: new\n"
+	}
+	var s []string
+	for _, name := range baselineMetrics {
+		if v, ok := d.Metrics[name]; ok {
+			s = append(s, fmt.Sprintf("%s %s", name, formatChange(v)))
+		}
+	}
+	if len(s) == 0 {
+		s = append(s, "unchanged")
+	}
+	return fmt.Sprintf("\tsince baseline: %s\n", strings.Join(s, ", "))
+}
+
+// formatChange is v with its sign, with ratios to 3 decimal places.
+func formatChange(v float64) string {
+	if v == math.Trunc(v) {
+		return fmt.Sprintf("%+g", v)
+	}
+	return fmt.Sprintf("%+.3f", v)
+}
+
+func writeRemoved(w io.Writer, removed []string) error {
+	if len(removed) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "removed since baseline: %s\n", strings.Join(removed, ", "))
+	return err
+}
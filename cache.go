@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Cache stores the counts of packages from versioned modules on disk
+// so that later runs over overlapping sets of modules can skip them.
+//
+// Entries are keyed by module path@version and package ID
+// and live under a directory for the version of this tool,
+// so any change to the tool invalidates everything.
+type Cache struct {
+	dir string
+}
+
+// OpenCache returns a cache rooted at dir or, if dir is empty,
+// in the user's cache directory.
+// Entries are further separated by salt,
+// which must describe every option that changes the results.
+//
+// If the version of this tool cannot be determined,
+// such as with go run or a build with uncommitted changes,
+// there is nothing to key the cache on and OpenCache returns nil, nil.
+func OpenCache(dir, salt string) (*Cache, error) {
+	v := ToolVersion()
+	if v == "" {
+		return nil, nil
+	}
+	if dir == "" {
+		ucd, err := os.UserCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(ucd, "issue57949")
+	}
+	h := sha256.Sum256([]byte(salt))
+	v += "-" + hex.EncodeToString(h[:8])
+	return &Cache{dir: filepath.Join(dir, url.PathEscape(v))}, nil
+}
+
+// resultFlags are the flags that change what is counted.
+var resultFlags = []string{
+	"exclude-files",
+	"skip-deprecated",
+	"only-types",
+	"ignore-types",
+	"cgo",
+	"types",
+	"rule",
+	"fold",
+	"split",
+	"duplicates",
+	"dedupe",
+	"top",
+	"top-types",
+	"recent",
+	"options-suffixes",
+	"sample",
+	"sample-files",
+	"seed",
+	"allow-type-errors",
+	"test",
+	"mod",
+	"tags",
+	"goos",
+	"goarch",
+	"env",
+	"overlay",
+}
+
+// CacheSalt returns the values of the resultFlags.
+func CacheSalt() string {
+	var b strings.Builder
+	for _, name := range resultFlags {
+		fmt.Fprintf(&b, "-%s=%s\n", name, LookupFlag(name).Value)
+	}
+	return b.String()
+}
+
+// ToolVersion returns the module version or VCS revision of this binary
+// or "" if it is unknown or the build had uncommitted changes.
+func ToolVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if v := bi.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	rev := ""
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				return ""
+			}
+		}
+	}
+	return rev
+}
+
+func (c *Cache) path(m *packages.Module, id string) (string, bool) {
+	// packages from the main module or a replacement can change without a version bump
+	if m == nil || m.Version == "" || m.Replace != nil {
+		return "", false
+	}
+	mod := url.PathEscape(m.Path + "@" + m.Version)
+	return filepath.Join(c.dir, mod, url.PathEscape(id)+".json"), true
+}
+
+// Get returns the cached count for p or nil.
+func (c *Cache) Get(p *packages.Package) *Count {
+	path, ok := c.path(p.Module, p.ID)
+	if !ok {
+		return nil
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	count := New(p.ID)
+	if err := json.Unmarshal(bs, count); err != nil {
+		return nil
+	}
+	return count
+}
+
+// Put records count as the result for p, if p can be cached.
+func (c *Cache) Put(p *packages.Package, count *Count) error {
+	path, ok := c.path(p.Module, p.ID)
+	if !ok {
+		return nil
+	}
+	bs, err := json.Marshal(count)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// write then rename so a concurrent run never sees a partial entry
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, bs, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Lookup lists the packages matching pattern and splits them
+// into those with cached counts and the package paths that must still be loaded.
+// The misses are loaded by path, so Lookup must not be used with -test,
+// where the ID of a test variant is not a pattern that loads it.
+func (c *Cache) Lookup(ctx context.Context, pattern []string) (hits []*Count, misses []string, err error) {
+	cfg, err := NewConfig(ctx, "", packages.NeedName|packages.NeedModule)
+	if err != nil {
+		return nil, nil, err
+	}
+	ps, err := LoadBatched(cfg, pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, p := range ps {
+		if count := c.Get(p); count != nil {
+			cacheHits.Add(1)
+			hits = append(hits, count)
+		} else {
+			misses = append(misses, p.PkgPath)
+		}
+	}
+	return hits, misses, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var cgoMode = NewEnum(filterFlags, "cgo", "source", "for packages using cgo, count the files as written, also the files cgo generates, or skip the package", "source", "generated", "skip")
+
+// UsesCgo reports whether p uses cgo.
+// The syntax of such a package is of the files after cgo translates them,
+// which //line comments map back to the files as written,
+// and the extra files cgo generates, which are only in the build cache.
+func UsesCgo(p *packages.Package) bool {
+	for _, f := range p.Syntax {
+		if IsCgoGenerated(p, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCgoGenerated reports whether f is one of the files generated by cgo
+// that do not correspond to a file of p as written, such as _cgo_gotypes.go.
+//
+// A file is as written if it was parsed from one of p.GoFiles,
+// even if //line comments name another file, as in the output of goyacc,
+// or if it is the translation of one, which cgo writes to the build cache
+// with //line comments naming the file as written.
+func IsCgoGenerated(p *packages.Package, f *ast.File) bool {
+	parsed := p.Fset.File(f.Pos()).Name()
+	written := p.Fset.Position(f.Package).Filename
+	for _, g := range p.GoFiles {
+		if g == parsed || g == written {
+			return false
+		}
+	}
+	return true
+}
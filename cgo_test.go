@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLineDirectiveNotCgo(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"expr.go": `//line expr.y:2
+package m
+
+type T struct{ A int }
+
+func f(A int) T { return T{A: A} }
+`,
+		"m.go": `package m
+
+func g(A int) T { return T{A: A} }
+`,
+	})
+	ps, _, err := GetPackages(context.Background(), dir, []string{"./..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if UsesCgo(ps[0]) {
+		t.Error("a file with a //line comment is reported as generated by cgo")
+	}
+	if c := CountPackage(context.Background(), ps[0]); c.Literals != 2 || c.Cgo != 0 {
+		t.Errorf("got %d literals in %d packages using cgo, want 2 in 0", c.Literals, c.Cgo)
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+	"golang.org/x/tools/go/packages"
+)
+
+// A Command is a subcommand.
+type Command struct {
+	Name  string
+	Usage string
+	Short string
+	// FlagSet returns the flags of the command.
+	FlagSet func() *flag.FlagSet
+	Run     func(ctx context.Context, w io.Writer, args []string) error
+}
+
+// commands are the subcommands. The first is the default.
+var commands = []*Command{
+	{
+		Name:  "count",
+		Usage: "[flags] [packages]",
+		Short: "count keyed struct literals in packages",
+		Run:   Main,
+	},
+	{
+		Name:  "list",
+		Usage: "[flags] [packages]",
+		Short: "list the packages that count would load",
+		Run:   List,
+	},
+	{
+		Name:  "diff",
+		Usage: "[flags] old.json new.json",
+		Short: "show how the metrics changed between two reports",
+		Run:   Diff,
+	},
+	{
+		Name:  "merge",
+		Usage: "[flags] report.json...",
+		Short: "combine reports written with -json",
+		Run:   Merge,
+	},
+	{
+		Name:  "corpus",
+		Usage: "[flags] [module dirs]",
+		Short: "count every package of many separate modules, with a total per module",
+		Run:   Corpus,
+	},
+	{
+		Name:  "repl",
+		Usage: "[flags] [packages]",
+		Short: "browse the counts of packages, files, types, and sites at a line-oriented prompt",
+		Run:   Browse,
+	},
+	{
+		Name:  "serve",
+		Usage: "[flags] [packages]",
+		Short: "count packages and serve a dashboard of the results over HTTP",
+		Run:   Serve,
+	},
+	{
+		Name:  "preview",
+		Usage: "[flags] [packages]",
+		Short: "print a diff of the code as it would be with keys elided from exact matches or, with -fix-names, local variables renamed to match",
+		Run:   Preview,
+	},
+	{
+		Name:  "export",
+		Usage: "[flags] [packages]",
+		Short: "write newline-delimited JSON rows per package or site, and optionally their BigQuery schema",
+		Run:   Export,
+	},
+	{
+		Name:  "treemap",
+		Usage: "[flags] [packages]",
+		Short: "write an SVG treemap of the packages by directory, sized by KV pairs and colored by exact ratio",
+		Run:   Treemap,
+	},
+	{
+		Name:  "selftest",
+		Usage: "[flags]",
+		Short: "check the classification of the pairs in embedded fixtures with known results",
+		Run:   SelfTest,
+	},
+	{
+		Name:  "vet",
+		Usage: "[flags] [packages]",
+		Short: "run as a go/analysis analyzer, reporting exact matches and partial matches with suggested fixes",
+		Run:   Vet,
+	},
+}
+
+func init() {
+	groups := map[string][]*flag.FlagSet{
+		"count":    {loadFlags, outputFlags, countFlags, filterFlags, gateFlags},
+		"list":     {loadFlags},
+		"diff":     {outputFlags},
+		"merge":    {outputFlags, gateFlags},
+		"corpus":   {loadFlags, outputFlags, filterFlags, gateFlags, corpusFlags},
+		"repl":     {loadFlags, filterFlags},
+		"serve":    {loadFlags, filterFlags, serveFlags},
+		"preview":  {loadFlags, outputFlags, filterFlags, previewFlags},
+		"export":   {loadFlags, outputFlags, filterFlags, exportFlags},
+		"treemap":  {loadFlags, outputFlags, filterFlags, treemapFlags},
+		"selftest": {},
+		"vet":      {loadFlags, vetFlags, &structlit.Analyzer.Flags},
+	}
+	for _, c := range commands {
+		c := c
+		c.FlagSet = func() *flag.FlagSet {
+			return NewFlagSet(c.Name, c.Usage, groups[c.Name]...)
+		}
+	}
+}
+
+func lookupCommand(name string) *Command {
+	for _, c := range commands {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Help prints the usage of the named command or a list of commands.
+func Help(args []string) {
+	if len(args) > 0 {
+		if c := lookupCommand(args[0]); c != nil {
+			fs := c.FlagSet()
+			fs.SetOutput(os.Stdout)
+			fs.Usage()
+			return
+		}
+	}
+	fmt.Println("usage: issue57949 [command] [flags] [args]")
+	fmt.Println()
+	fmt.Println("The commands are:")
+	for _, c := range commands {
+		fmt.Printf("\t%-8s %s\n", c.Name, c.Short)
+	}
+	fmt.Println()
+	fmt.Printf("The default command is %s. Use issue57949 help [command] for its flags.\n", commands[0].Name)
+}
+
+// List prints the ID of each package matching args.
+func List(ctx context.Context, w io.Writer, args []string) error {
+	args, err := Patterns(ctx, args)
+	if err != nil {
+		return err
+	}
+	cfg, err := NewConfig(ctx, "", packages.NeedName)
+	if err != nil {
+		return err
+	}
+	ps, err := LoadBatched(cfg, args)
+	if err != nil {
+		return err
+	}
+	if err := LoadErrors(ps); err != nil {
+		return err
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		return ps[i].ID < ps[j].ID
+	})
+	for _, p := range ps {
+		fmt.Fprintln(w, p.ID)
+	}
+	return nil
+}
+
+// Merge combines reports and writes the result.
+func Merge(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) == 0 {
+		return errors.New("merge: no reports")
+	}
+	var rs []*Report
+	for _, name := range args {
+		r, err := ReadReport(name)
+		if err != nil {
+			return err
+		}
+		rs = append(rs, r)
+	}
+	m := MergeReports(rs)
+	if err := m.Write(w); err != nil {
+		return err
+	}
+	return finish(ctx, m.Total)
+}
+
+// Diff prints every metric that differs between two reports,
+// for each package, module, and the total.
+func Diff(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) != 2 {
+		return errors.New("diff: need exactly two reports")
+	}
+	if *jsonOut {
+		return errors.New("diff: -json is not supported")
+	}
+	old, err := ReadReport(args[0])
+	if err != nil {
+		return err
+	}
+	cur, err := ReadReport(args[1])
+	if err != nil {
+		return err
+	}
+
+	diff := func(o, n []*Count) {
+		byID := map[string][2]*Count{}
+		for _, c := range o {
+			byID[c.ID] = [2]*Count{c, byID[c.ID][1]}
+		}
+		for _, c := range n {
+			byID[c.ID] = [2]*Count{byID[c.ID][0], c}
+		}
+		var ids []string
+		for id := range byID {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			pair := byID[id]
+			fmt.Fprint(w, DiffCounts(id, pair[0], pair[1]))
+		}
+	}
+	diff(old.Packages, cur.Packages)
+	diff(old.Modules, cur.Modules)
+	diff([]*Count{old.Total}, []*Count{cur.Total})
+	return nil
+}
+
+// DiffCounts describes the metrics that differ between o and n.
+// Either may be nil if the count is only in one report.
+// It returns "" if nothing changed.
+func DiffCounts(id string, o, n *Count) string {
+	var b strings.Builder
+	both := o != nil && n != nil
+	switch {
+	case o == nil:
+		fmt.Fprintf(&b, "%s: (added)\n", id)
+		o = New(id)
+	case n == nil:
+		fmt.Fprintf(&b, "%s: (removed)\n", id)
+		n = New(id)
+	default:
+		fmt.Fprintf(&b, "%s:\n", id)
+	}
+	header := b.Len()
+
+	om, nm := o.Metrics(), n.Metrics()
+	for _, name := range MetricNames() {
+		ov, nv := om[name], nm[name]
+		if ov == nv {
+			continue
+		}
+		fmt.Fprintf(&b, "\t%s: %s -> %s (%+g)\n", name, formatMetric(ov), formatMetric(nv), nv-ov)
+	}
+	if both && b.Len() == header {
+		return ""
+	}
+	return b.String()
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConfigName is the name of the file searched for by FindConfig.
+const ConfigName = ".structlitcount.toml"
+
+var configFile = commonFlags.String("config", "", "read default flags from `file` (default: "+ConfigName+" in the working directory or a parent up to the module root; \"none\" to disable)")
+
+// FindConfig looks for ConfigName in dir and its parents,
+// stopping at the first directory containing a go.mod.
+// It returns "" if there is none.
+func FindConfig(dir string) string {
+	for {
+		name := filepath.Join(dir, ConfigName)
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ApplyConfig sets every flag in fs that was not given on the command line
+// to the value recorded for it in the config file, if any.
+// Flags that belong to other commands are ignored.
+func ApplyConfig(fs *flag.FlagSet) error {
+	name := *configFile
+	if name == "none" {
+		return nil
+	}
+	if name == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		// -C must be given on the command line for this to see it
+		if filepath.IsAbs(*chdir) {
+			wd = *chdir
+		} else if *chdir != "" {
+			wd = filepath.Join(wd, *chdir)
+		}
+		if name = FindConfig(wd); name == "" {
+			return nil
+		}
+	}
+
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	values, err := ParseConfig(name, string(bs))
+	if err != nil {
+		return err
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	for _, s := range values {
+		if s.Flag == "config" {
+			return fmt.Errorf("%s: config cannot set config", name)
+		}
+		if set[s.Flag] {
+			continue
+		}
+		if LookupFlag(s.Flag) == nil {
+			return fmt.Errorf("%s: unknown flag %q", name, s.Flag)
+		}
+		// for another command
+		f := fs.Lookup(s.Flag)
+		if f == nil {
+			continue
+		}
+		vs := s.Values
+		// a plain string flag, such as -tags, keeps only the value it was last set to,
+		// so it is given the elements as the comma-separated list it takes
+		if g, ok := f.Value.(flag.Getter); ok {
+			if _, ok := g.Get().(string); ok {
+				vs = []string{strings.Join(vs, ",")}
+			}
+		}
+		for _, v := range vs {
+			if err := fs.Set(s.Flag, v); err != nil {
+				return fmt.Errorf("%s: %s: %w", name, s.Flag, err)
+			}
+		}
+	}
+	return nil
+}
+
+// A ConfigSetting is a flag and the values a config file gives it:
+// one or, for an array, each element, to be set in turn
+// as if the flag were repeated.
+type ConfigSetting struct {
+	Flag   string
+	Values []string
+}
+
+// ParseConfig parses the subset of TOML used by config files:
+// top-level key = value pairs, where the key is a flag name
+// and the value is a string, boolean, number, or single-line array of those.
+// The settings are returned in file order.
+func ParseConfig(name, src string) ([]ConfigSetting, error) {
+	var settings []ConfigSetting
+	for i, line := range strings.Split(src, "\n") {
+		errorf := func(format string, args ...any) error {
+			return fmt.Errorf("%s:%d: %s", name, i+1, fmt.Sprintf(format, args...))
+		}
+
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return nil, errorf("tables are not supported")
+		}
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, errorf("expected key = value")
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, errorf("missing key")
+		}
+		vs, err := configValues(strings.TrimSpace(val))
+		if err != nil {
+			return nil, errorf("%s: %v", key, err)
+		}
+		settings = append(settings, ConfigSetting{key, vs})
+	}
+	return settings, nil
+}
+
+// stripComment removes any # comment not inside a string.
+func stripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// configValues returns the elements of the array v or else v alone.
+func configValues(v string) ([]string, error) {
+	if !strings.HasPrefix(v, "[") {
+		s, err := configValue(v)
+		if err != nil {
+			return nil, err
+		}
+		return []string{s}, nil
+	}
+	if !strings.HasSuffix(v, "]") {
+		return nil, fmt.Errorf("arrays must be on one line")
+	}
+	var elems []string
+	for _, e := range splitArray(v[1 : len(v)-1]) {
+		e = strings.TrimSpace(e)
+		// allow a trailing comma
+		if e == "" {
+			continue
+		}
+		s, err := configValue(e)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, s)
+	}
+	return elems, nil
+}
+
+func configValue(v string) (string, error) {
+	switch {
+	case v == "":
+		return "", fmt.Errorf("missing value")
+	case strings.HasPrefix(v, "["):
+		return "", fmt.Errorf("arrays cannot be nested")
+	case strings.HasPrefix(v, `"`):
+		return strconv.Unquote(v)
+	case strings.HasPrefix(v, "'"):
+		if len(v) < 2 || !strings.HasSuffix(v, "'") {
+			return "", fmt.Errorf("unterminated string")
+		}
+		return v[1 : len(v)-1], nil
+	case v == "true" || v == "false":
+		return v, nil
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(v, "_", ""), 64); err != nil {
+		return "", fmt.Errorf("unsupported value %s", v)
+	}
+	return strings.ReplaceAll(v, "_", ""), nil
+}
+
+// splitArray splits the inside of an array on commas not inside strings.
+func splitArray(s string) []string {
+	var (
+		elems []string
+		quote rune
+		start int
+	)
+	escaped := false
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			elems = append(elems, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(elems, s[start:])
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"sort"
+	"strings"
+)
+
+// BuildConstraint returns the //go:build constraint of f, as written by gofmt,
+// or "" if it has none.
+func BuildConstraint(f *ast.File) string {
+	for _, g := range f.Comments {
+		if g.Pos() > f.Package {
+			break
+		}
+		for _, c := range g.List {
+			if !constraint.IsGoBuild(c.Text) {
+				continue
+			}
+			expr, err := constraint.Parse(c.Text)
+			if err != nil {
+				// the go command would not have loaded the file
+				return ""
+			}
+			return expr.String()
+		}
+	}
+	return ""
+}
+
+// constraintsShown is how many constraints the text report lists.
+const constraintsShown = 5
+
+// formatConstraints lists the constraints with the most literals first
+// and how many literals there are in all.
+func (c *Count) formatConstraints() string {
+	var total uint64
+	var exprs []string
+	for e, n := range c.Constraints {
+		total += n
+		exprs = append(exprs, e)
+	}
+	sort.Slice(exprs, func(i, j int) bool {
+		ni, nj := c.Constraints[exprs[i]], c.Constraints[exprs[j]]
+		if ni != nj {
+			return ni > nj
+		}
+		return exprs[i] < exprs[j]
+	})
+	var s []string
+	for i, e := range exprs {
+		if i == constraintsShown {
+			s = append(s, fmt.Sprintf("%d more", len(exprs)-i))
+			break
+		}
+		s = append(s, fmt.Sprintf("%s %d", e, c.Constraints[e]))
+	}
+	return fmt.Sprintf("%d (%s)", total, strings.Join(s, ", "))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+)
+
+var (
+	corpusModules  = corpusFlags.String("modules", "", "read module directories from `file`, one per line")
+	corpusPackages = corpusFlags.Bool("packages", false, "also report each package")
+)
+
+// Corpus counts all the packages in each module directory in args,
+// loading each module on its own as independent modules cannot be loaded together.
+func Corpus(ctx context.Context, w io.Writer, args []string) error {
+	if *corpusModules != "" {
+		more, err := ReadPatterns(*corpusModules)
+		if err != nil {
+			return err
+		}
+		args = append(args, more...)
+	}
+	if len(args) == 0 {
+		return errors.New("corpus: no module directories")
+	}
+	if err := alerts.NeedSplits(); err != nil {
+		return err
+	}
+
+	r := &Report{Total: New("<total>")}
+	for _, dir := range args {
+		if ctx.Err() != nil {
+			break
+		}
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		ps, skipped, err := GetPackages(ctx, dir, []string{"./..."})
+		if err != nil {
+			return err
+		}
+		r.Skipped = append(r.Skipped, skipped...)
+
+		id := dir
+		if len(ps) > 0 && ps[0].Module != nil {
+			id = ps[0].Module.Path
+		}
+		m := New("<module " + id + ">")
+		for _, p := range ps {
+			if ctx.Err() != nil {
+				break
+			}
+			c := CountPackage(ctx, p)
+			m.Add(c)
+			if *corpusPackages {
+				r.Packages = append(r.Packages, c)
+			}
+		}
+		r.Modules = append(r.Modules, m)
+		r.Total.Add(m)
+	}
+
+	SortCounts(r.Packages)
+	SortCounts(r.Modules)
+	if err := r.Write(w); err != nil {
+		return err
+	}
+	return finish(ctx, r.Total)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// document reports whether -out asks for a single JSON document.
+func document() bool {
+	return outFormat.Value == "document"
+}
+
+// Document is everything about a run in one JSON document:
+// what ran, the report, and every site counted.
+type Document struct {
+	Meta   *Meta     `json:"meta"`
+	Report *Report   `json:"report"`
+	Sites  []siteRow `json:"sites"`
+}
+
+// writeDocument writes r and sites as a Document.
+// The sites are sorted by package, file, and position.
+func writeDocument(w io.Writer, r *Report, sites []*Site) error {
+	d := &Document{
+		Meta:   NewMeta(),
+		Report: r,
+		Sites:  []siteRow{},
+	}
+	for _, s := range sites {
+		d.Sites = append(d.Sites, newSiteRow(s))
+	}
+	sort.SliceStable(d.Sites, func(i, j int) bool {
+		a, b := d.Sites[i], d.Sites[j]
+		switch {
+		case a.Package != b.Package:
+			return a.Package < b.Package
+		case a.File != b.File:
+			return a.File < b.File
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(d)
+}
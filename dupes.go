@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	findDuplicates = countFlags.Bool("duplicates", false, "count the literals that are exact duplicates of another earlier in the run, such as copied fixtures")
+	dedupe         = countFlags.Bool("dedupe", false, "like -duplicates but also count the literals of each duplicate only once")
+)
+
+// firstLiterals is the position of the first literal with each hash
+// for -duplicates and -dedupe.
+var firstLiterals = map[[sha256.Size]byte]token.Position{}
+
+// ForgetLiterals forgets every literal seen,
+// as before counting every package again after a change.
+func ForgetLiterals() {
+	firstLiterals = map[[sha256.Size]byte]token.Position{}
+}
+
+// Duplicate reports whether a literal the same as c, once printed
+// without comments or formatting, was seen earlier in the run
+// at another position, which is the same literal counted twice.
+func Duplicate(p *packages.Package, c *ast.CompositeLit, typ types.Type) bool {
+	var b bytes.Buffer
+	// the type distinguishes literals with the type elided
+	b.WriteString(typ.String())
+	b.WriteByte(0)
+	if err := printer.Fprint(&b, p.Fset, c); err != nil {
+		return false
+	}
+	h := sha256.Sum256(b.Bytes())
+	pos := p.Fset.Position(c.Pos())
+	first, ok := firstLiterals[h]
+	if !ok {
+		firstLiterals[h] = pos
+		return false
+	}
+	return first != pos
+}
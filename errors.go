@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	errorPolicy     = NewEnum(loadFlags, "errors", "fail", "when packages have errors, fail the run, skip them and log their errors, or skip them and report their errors with the results", "fail", "skip", "report")
+	keepGoing       = loadFlags.Bool("keep-going", false, "the same as -errors=report")
+	allowTypeErrors = loadFlags.Bool("allow-type-errors", false, "count packages with type errors as best as possible instead of failing, logging the errors")
+)
+
+var errorFormat = NewEnum(commonFlags, "error-format", "text", "how to write errors from loading packages to stderr", "text", "json")
+
+// PackageError is an error from loading a package.
+type PackageError struct {
+	Package string `json:"package"`
+	// Pos is file:line:col, or "" if unknown.
+	Pos string `json:"pos,omitempty"`
+	// Kind is list (such as a missing dependency), parse, type, or unknown.
+	Kind string `json:"kind"`
+	Msg  string `json:"msg"`
+}
+
+func (e PackageError) String() string {
+	pos := e.Pos
+	if pos == "" {
+		pos = "-"
+	}
+	return pos + ": " + e.Msg
+}
+
+// LoadError is returned when any package could not be loaded.
+type LoadError struct {
+	Errors []PackageError `json:"errors"`
+}
+
+func (e *LoadError) Error() string {
+	return "could not load packages"
+}
+
+// Write writes the individual errors in the -error-format.
+func (e *LoadError) Write(w io.Writer) error {
+	if errorFormat.Value == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(e)
+	}
+	for _, pe := range e.Errors {
+		if _, err := fmt.Fprintln(w, pe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadErrors returns a *LoadError with the errors of ps and their dependencies
+// or nil if there are none.
+func LoadErrors(ps []*packages.Package) error {
+	var errs []PackageError
+	// same traversal as packages.PrintErrors
+	packages.Visit(ps, nil, func(p *packages.Package) {
+		for _, err := range p.Errors {
+			errs = append(errs, PackageError{
+				Package: p.ID,
+				Pos:     err.Pos,
+				Kind:    errorKind(err.Kind),
+				Msg:     err.Msg,
+			})
+		}
+	})
+	if len(errs) == 0 {
+		return nil
+	}
+	return &LoadError{Errors: errs}
+}
+
+// SkipErrors removes the packages in ps with errors of their own
+// and returns the errors of those removed.
+func SkipErrors(ps []*packages.Package) (ok []*packages.Package, skipped []PackageError) {
+	for _, p := range ps {
+		if len(p.Errors) == 0 {
+			ok = append(ok, p)
+			continue
+		}
+		for _, err := range p.Errors {
+			skipped = append(skipped, PackageError{
+				Package: p.ID,
+				Pos:     err.Pos,
+				Kind:    errorKind(err.Kind),
+				Msg:     err.Msg,
+			})
+		}
+	}
+	return ok, skipped
+}
+
+// DropTypeErrors removes the type errors from ps and their dependencies
+// and logs them, so that only the other errors stop a package from being counted.
+// With type errors some literals are missing type information
+// and are counted as Untyped, unless LitType can resolve their type.
+func DropTypeErrors(ps []*packages.Package) {
+	packages.Visit(ps, nil, func(p *packages.Package) {
+		typeErrors := false
+		for _, err := range p.Errors {
+			if err.Kind == packages.TypeError {
+				typeErrors = true
+				log.Printf("type error in %s: %s", p.ID, err)
+			}
+		}
+		var keep []packages.Error
+		for _, err := range p.Errors {
+			// go list compiling the package to get its export data
+			// reports the type errors again as "# path\nerrors"
+			compiled := err.Kind == packages.ListError && strings.HasPrefix(err.Msg, "# ")
+			if err.Kind == packages.TypeError || (typeErrors && compiled) {
+				continue
+			}
+			keep = append(keep, err)
+		}
+		p.Errors = keep
+	})
+}
+
+// ErrorPolicy returns the -errors policy,
+// which is report if it is not given and -keep-going is.
+func ErrorPolicy() string {
+	if *keepGoing && errorPolicy.Value == "fail" {
+		return "report"
+	}
+	return errorPolicy.Value
+}
+
+// LogSkipped logs the errors of any packages skipped by -errors.
+func LogSkipped(skipped []PackageError) {
+	for _, e := range skipped {
+		log.Printf("skipped %s: %s", e.Package, e)
+	}
+}
+
+func errorKind(k packages.ErrorKind) string {
+	switch k {
+	case packages.ListError:
+		return "list"
+	case packages.ParseError:
+		return "parse"
+	case packages.TypeError:
+		return "type"
+	}
+	return "unknown"
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	exportRows   = NewEnum(exportFlags, "rows", "packages", "write a row per package, with every metric, or per KV pair", "packages", "sites")
+	exportSchema = exportFlags.String("schema", "", "also write the BigQuery schema of the rows to `file`")
+)
+
+// Export runs the export command: it writes newline-delimited JSON,
+// a row per package or per site, for loading into BigQuery or the like,
+// after a metaRecord.
+func Export(ctx context.Context, w io.Writer, args []string) error {
+	if *jsonOut {
+		return errors.New("export: -json is not supported; the rows are always JSON")
+	}
+	args, err := Patterns(ctx, args)
+	if err != nil {
+		return err
+	}
+	ps, skipped, err := GetPackages(ctx, "", args)
+	if err != nil {
+		return err
+	}
+	LogSkipped(skipped)
+
+	if *exportSchema != "" {
+		if err := writeSchema(*exportSchema, exportRows.Value == "sites"); err != nil {
+			return err
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(metaRecord{NewMeta()}); err != nil {
+		return err
+	}
+	for _, p := range ps {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var sites []*Site
+		c := CountPackageFunc(ctx, p, func(s *Site) {
+			sites = append(sites, s)
+		})
+		if exportRows.Value == "packages" {
+			if err := enc.Encode(packageRow(c)); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, s := range sites {
+			if err := enc.Encode(newSiteRow(s)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// columnName turns a metric name into a valid column name.
+func columnName(metric string) string {
+	return strings.NewReplacer(".", "_", "-", "_").Replace(metric)
+}
+
+// isFloatMetric reports whether the metric is not a whole number.
+func isFloatMetric(metric string) bool {
+	for _, s := range []string{"ratio", "mean", "median", "per_kloc"} {
+		if strings.Contains(metric, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// packageRow is the package ID and every metric of c.
+func packageRow(c *Count) map[string]any {
+	row := map[string]any{"package": c.ID}
+	for name, v := range c.Metrics() {
+		if isFloatMetric(name) {
+			row[columnName(name)] = v
+		} else {
+			row[columnName(name)] = int64(v)
+		}
+	}
+	return row
+}
+
+var sitesFormat = NewEnum(countFlags, "sites", "none", "write, for jsonl, a JSON object per KV pair, the same as export -rows=sites, or, for csv, a CSV row per KV pair after a header, instead of the report", "none", "jsonl", "csv")
+
+// siteRecords reports whether -sites asks for a record per site.
+func siteRecords() bool {
+	return sitesFormat.Value != "none"
+}
+
+// siteWriter writes a record per site in the format of -sites.
+type siteWriter struct {
+	json *json.Encoder
+	csv  *csv.Writer
+}
+
+// newSiteWriter returns a siteWriter to w,
+// having written the Meta of this run and, for CSV, the header.
+func newSiteWriter(w io.Writer) (*siteWriter, error) {
+	if sitesFormat.Value != "csv" {
+		sw := &siteWriter{json: json.NewEncoder(w)}
+		return sw, sw.json.Encode(metaRecord{NewMeta()})
+	}
+	if err := writeMetaComment(w); err != nil {
+		return nil, err
+	}
+	sw := &siteWriter{csv: csv.NewWriter(w)}
+	return sw, sw.csv.Write(siteColumns)
+}
+
+func (sw *siteWriter) Write(s *Site) error {
+	r := newSiteRow(s)
+	if sw.json != nil {
+		return sw.json.Encode(r)
+	}
+	return sw.csv.Write([]string{r.Package, r.File, strconv.Itoa(r.Line), strconv.Itoa(r.Column), r.Key, r.Value, r.Type, r.FieldType, r.ValueKind, r.Kind, r.Match})
+}
+
+// Flush writes any buffered records.
+func (sw *siteWriter) Flush() error {
+	if sw.csv == nil {
+		return nil
+	}
+	sw.csv.Flush()
+	return sw.csv.Error()
+}
+
+// siteColumns are the columns of a siteRow in order.
+var siteColumns = []string{"package", "file", "line", "column", "key", "value", "type", "field_type", "value_kind", "kind", "match"}
+
+// siteRow is a Site flattened into columns.
+type siteRow struct {
+	Package string `json:"package"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Type    string `json:"type"`
+	// FieldType is "" when the key is not a field, which is a type error.
+	FieldType string `json:"field_type"`
+	ValueKind string `json:"value_kind"`
+	Kind      string `json:"kind"`
+	Match     string `json:"match"`
+}
+
+func newSiteRow(s *Site) siteRow {
+	return siteRow{
+		Package:   s.Package,
+		File:      s.Pos.Filename,
+		Line:      s.Pos.Line,
+		Column:    s.Pos.Column,
+		Key:       s.Key,
+		Value:     s.Value,
+		Type:      s.Type,
+		FieldType: s.FieldType,
+		ValueKind: s.ValueKind,
+		Kind:      s.Match.Kind(),
+		Match:     s.Match.Result(),
+	}
+}
+
+// schemaField is a column of a BigQuery schema file.
+type schemaField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Mode string `json:"mode"`
+}
+
+func writeSchema(name string, sites bool) error {
+	var fields []schemaField
+	if sites {
+		for _, col := range siteColumns {
+			typ := "STRING"
+			if col == "line" || col == "column" {
+				typ = "INTEGER"
+			}
+			fields = append(fields, schemaField{col, typ, "REQUIRED"})
+		}
+	} else {
+		fields = append(fields, schemaField{"package", "STRING", "REQUIRED"})
+		for _, m := range MetricNames() {
+			typ := "INTEGER"
+			if isFloatMetric(m) {
+				typ = "FLOAT"
+			}
+			fields = append(fields, schemaField{columnName(m), typ, "REQUIRED"})
+		}
+	}
+	bs, err := json.MarshalIndent(fields, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(bs, '\n'), 0o644)
+}
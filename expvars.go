@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"log"
+	"net"
+	"net/http"
+)
+
+var debugAddr = countFlags.String("debug-addr", "", "while -watch runs, serve expvar counters at /debug/vars on `addr`")
+
+// The counters of a long-running process, served at /debug/vars
+// by serve and by -watch with -debug-addr.
+var (
+	packagesAnalyzed = expvar.NewInt("packages_analyzed")
+	literalsCounted  = expvar.NewInt("literals_counted")
+	cacheHits        = expvar.NewInt("cache_hits")
+	loadErrors       = expvar.NewInt("load_errors")
+)
+
+// ServeDebug serves the expvar counters on addr until ctx is done.
+func ServeDebug(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	srv := &http.Server{Handler: mux}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	go func() {
+		if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			log.Println(err)
+		}
+	}()
+	log.Printf("serving counters on http://%s/debug/vars", ln.Addr())
+	return nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+)
+
+var excludeFiles Globs
+
+func init() {
+	filterFlags.Var(&excludeFiles, "exclude-files", "skip files matching any of the comma-separated `globs`; globs without a / match the base name and relative globs with one, such as internal/gen/*.go, the trailing elements of the name")
+}
+
+// Globs is a flag.Value of comma-separated filepath.Match patterns.
+type Globs []string
+
+func (g *Globs) String() string {
+	return strings.Join(*g, ",")
+}
+
+// Set adds the comma-separated patterns in s.
+func (g *Globs) Set(s string) error {
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		if _, err := filepath.Match(p, ""); err != nil {
+			return err
+		}
+		*g = append(*g, p)
+	}
+	return nil
+}
+
+// Match reports whether name matches any pattern.
+// Absolute patterns are matched against the whole name,
+// other patterns containing a / against as many trailing elements of the name,
+// so internal/gen/*.go matches /src/m/internal/gen/a.go,
+// and the rest against its base name.
+func (g Globs) Match(name string) bool {
+	name = filepath.ToSlash(name)
+	for _, p := range g {
+		target := name
+		if !strings.HasPrefix(p, "/") {
+			target = trailing(name, strings.Count(p, "/")+1)
+		}
+		if ok, _ := filepath.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// trailing returns the last n elements of the slash-separated name.
+func trailing(name string, n int) string {
+	i := len(name)
+	for ; n > 0 && i > 0; n-- {
+		i = strings.LastIndex(name[:i], "/")
+	}
+	return name[i+1:]
+}
+
+var onlyTypes, ignoreTypes TypeNames
+
+func init() {
+	filterFlags.Var(&onlyTypes, "only-types", "count only the literals and field assignments of the comma-separated struct `types`, such as net/http.Server or http.Server")
+	filterFlags.Var(&ignoreTypes, "ignore-types", "do not count the literals and field assignments of the comma-separated struct `types`, named as for -only-types")
+}
+
+// TypeNames is a flag.Value of comma-separated named types,
+// each qualified by the path or the name of its package.
+type TypeNames []string
+
+func (t *TypeNames) String() string {
+	return strings.Join(*t, ",")
+}
+
+// Set adds the comma-separated type names in s.
+func (t *TypeNames) Set(s string) error {
+	for _, n := range strings.Split(s, ",") {
+		if n = strings.TrimSpace(n); n == "" {
+			continue
+		}
+		if i := strings.LastIndex(n, "."); i <= 0 || i == len(n)-1 {
+			return fmt.Errorf("%q is not pkg.Type", n)
+		}
+		*t = append(*t, n)
+	}
+	return nil
+}
+
+// Match reports whether typ, or what it points to, is one of the named types.
+// Instances of a generic type match its name without type arguments.
+func (t TypeNames) Match(typ types.Type) bool {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Origin().Obj()
+	if obj.Pkg() == nil {
+		return false
+	}
+	for _, n := range t {
+		if n == obj.Pkg().Path()+"."+obj.Name() || n == obj.Pkg().Name()+"."+obj.Name() {
+			return true
+		}
+	}
+	return false
+}
+
+// CountedType reports whether a literal of typ is counted
+// under -only-types and -ignore-types.
+func CountedType(typ types.Type) bool {
+	return (len(onlyTypes) == 0 || onlyTypes.Match(typ)) && !ignoreTypes.Match(typ)
+}
+
+var skipDeprecated = filterFlags.Bool("skip-deprecated", false, "skip declarations whose doc comment has a Deprecated: paragraph")
+
+// Skipped returns whether pos in f should not be counted
+// because it is in a declaration skipped by -skip-deprecated.
+func Skipped(f *ast.File) func(token.Pos) bool {
+	if !*skipDeprecated {
+		return func(token.Pos) bool { return false }
+	}
+	var skip []ast.Node
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if isDeprecated(d.Doc) {
+				skip = append(skip, d)
+			}
+		case *ast.GenDecl:
+			if isDeprecated(d.Doc) {
+				skip = append(skip, d)
+				continue
+			}
+			for _, s := range d.Specs {
+				switch s := s.(type) {
+				case *ast.ValueSpec:
+					if isDeprecated(s.Doc) {
+						skip = append(skip, s)
+					}
+				case *ast.TypeSpec:
+					if isDeprecated(s.Doc) {
+						skip = append(skip, s)
+					}
+				}
+			}
+		}
+	}
+	return func(pos token.Pos) bool {
+		for _, n := range skip {
+			if n.Pos() <= pos && pos < n.End() {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// isDeprecated reports whether doc has a paragraph starting with Deprecated:.
+func isDeprecated(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, p := range strings.Split(doc.Text(), "\n\n") {
+		if strings.HasPrefix(p, "Deprecated: ") {
+			return true
+		}
+	}
+	return false
+}
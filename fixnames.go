@@ -0,0 +1,40 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+	"golang.org/x/tools/go/packages"
+)
+
+var fixNames = previewFlags.Bool("fix-names", false, "rename local variables to the key they are the value of when they only differ in case, instead of eliding keys")
+
+// NameFixes returns the edits, by file, that rename local variables
+// to the key of the pairs they are the value of when they only differ in case,
+// such as addr in Addr: addr, so that a shorthand could be used.
+// The renames are those of structlit.Renames for the literals counted.
+func NameFixes(p *packages.Package) map[string][]Edit {
+	var files []*ast.File
+	for _, f := range p.Syntax {
+		if !excludeFiles.Match(p.Fset.Position(f.Package).Filename) {
+			files = append(files, f)
+		}
+	}
+	skipped := map[*ast.File]func(token.Pos) bool{}
+	renames := structlit.Renames(p.Types, p.TypesInfo, files, foldMatcher, func(f *ast.File, c *ast.CompositeLit, typ types.Type) bool {
+		if skipped[f] == nil {
+			skipped[f] = Skipped(f)
+		}
+		return !skipped[f](c.Pos()) && CountedType(typ)
+	})
+	edits := map[string][]Edit{}
+	for _, r := range renames {
+		for _, id := range r.Idents {
+			pos := p.Fset.Position(id.Pos())
+			edits[pos.Filename] = append(edits[pos.Filename], Edit{pos.Offset, pos.Offset + len(id.Name), r.Name})
+		}
+	}
+	return edits
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+)
+
+// Flags are registered in groups and each command takes the groups it needs.
+var (
+	// commonFlags are accepted by every command.
+	commonFlags = newGroup()
+	// loadFlags control which packages are loaded and how.
+	loadFlags = newGroup()
+	// outputFlags control where and how reports are written.
+	outputFlags = newGroup()
+	// countFlags are specific to counting packages.
+	countFlags = newGroup()
+	// filterFlags control what is counted within a package.
+	filterFlags = newGroup()
+	// gateFlags fail the run based on the total.
+	gateFlags = newGroup()
+	// corpusFlags are specific to the corpus command.
+	corpusFlags = newGroup()
+	// serveFlags are specific to the serve command.
+	serveFlags = newGroup()
+	// previewFlags are specific to the preview command.
+	previewFlags = newGroup()
+	// exportFlags are specific to the export command.
+	exportFlags = newGroup()
+	// treemapFlags are specific to the treemap command.
+	treemapFlags = newGroup()
+	// vetFlags are specific to the vet command,
+	// which also takes the flags of structlit.Analyzer.
+	vetFlags = newGroup()
+)
+
+var groups = []*flag.FlagSet{commonFlags, loadFlags, outputFlags, countFlags, filterFlags, gateFlags, corpusFlags, serveFlags, previewFlags, exportFlags, treemapFlags, vetFlags, &structlit.Analyzer.Flags}
+
+func newGroup() *flag.FlagSet {
+	return flag.NewFlagSet("", flag.ContinueOnError)
+}
+
+// NewFlagSet returns the flags for the named command made up of the given groups.
+func NewFlagSet(name, usage string, gs ...*flag.FlagSet) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	for _, g := range append([]*flag.FlagSet{commonFlags}, gs...) {
+		g.VisitAll(func(f *flag.Flag) {
+			fs.Var(f.Value, f.Name, f.Usage)
+		})
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: issue57949 %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// LookupFlag returns the named flag from any group or nil.
+func LookupFlag(name string) *flag.Flag {
+	for _, g := range groups {
+		if f := g.Lookup(name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// Enum is a flag.Value that must be one of a fixed set of strings.
+type Enum struct {
+	Value  string
+	Values []string
+}
+
+// NewEnum defines an Enum flag in fs with the default def,
+// which must be one of values.
+func NewEnum(fs *flag.FlagSet, name, def, usage string, values ...string) *Enum {
+	e := &Enum{Value: def, Values: values}
+	fs.Var(e, name, fmt.Sprintf("%s (one of %s)", usage, strings.Join(values, ", ")))
+	return e
+}
+
+func (e *Enum) String() string {
+	return e.Value
+}
+
+func (e *Enum) Set(s string) error {
+	for _, v := range e.Values {
+		if s == v {
+			e.Value = s
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %s", strings.Join(e.Values, ", "))
+}
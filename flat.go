@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// JSONMode is the -json flag: like a boolean flag, -json alone is true,
+// and -json=flat is JSON with every count flattened into dotted keys.
+type JSONMode struct {
+	on, flat bool
+}
+
+func (m *JSONMode) IsBoolFlag() bool { return true }
+
+func (m *JSONMode) String() string {
+	switch {
+	case m == nil || !m.on:
+		return "false"
+	case m.flat:
+		return "flat"
+	}
+	return "true"
+}
+
+func (m *JSONMode) Set(s string) error {
+	if s == "flat" {
+		m.on, m.flat = true, true
+		return nil
+	}
+	on, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("must be a boolean or flat")
+	}
+	m.on, m.flat = on, false
+	return nil
+}
+
+// flatReport is a Report with each count flattened by flatten.
+type flatReport struct {
+	Meta     *Meta            `json:"meta,omitempty"`
+	Packages []map[string]any `json:"packages"`
+	Modules  []map[string]any `json:"modules,omitempty"`
+	Total    map[string]any   `json:"total"`
+	Skipped  []PackageError   `json:"skipped,omitempty"`
+	Sample   *Sample          `json:"sample,omitempty"`
+	Baseline *BaselineReport  `json:"baseline,omitempty"`
+}
+
+func (r *Report) flat() (*flatReport, error) {
+	f := &flatReport{Meta: r.Meta, Skipped: r.Skipped, Sample: r.Sample, Baseline: r.Baseline, Packages: []map[string]any{}}
+	var err error
+	for _, c := range r.Packages {
+		m, err := flatten(c)
+		if err != nil {
+			return nil, err
+		}
+		f.Packages = append(f.Packages, m)
+	}
+	for _, c := range r.Modules {
+		m, err := flatten(c)
+		if err != nil {
+			return nil, err
+		}
+		f.Modules = append(f.Modules, m)
+	}
+	f.Total, err = flatten(r.Total)
+	return f, err
+}
+
+// flatten returns the JSON object of c with every nested object and array
+// replaced by its elements under dotted keys,
+// such as ident.exact for {"ident": {"exact": 1}} and exact_fraction.0 for the first of exact_fraction.
+func flatten(c *Count) (map[string]any, error) {
+	bs, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	// keep integers as written
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	out := map[string]any{}
+	flattenInto(out, "", v)
+	return out, nil
+}
+
+func flattenInto(out map[string]any, prefix string, v any) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			flattenInto(out, join(k), e)
+		}
+	case []any:
+		for i, e := range v {
+			flattenInto(out, join(strconv.Itoa(i)), e)
+		}
+	default:
+		out[prefix] = v
+	}
+}
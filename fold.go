@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+)
+
+var foldMode = NewEnum(countFlags, "fold", "unicode", "the case folding under which a pair that is not exact is a partial match: full Unicode folding as strings.EqualFold, ASCII letters only, or only the first rune, as in Name: name", FoldNames...)
+
+// FoldNames are the case foldings -fold can name, in the order they are reported.
+var FoldNames = []string{"unicode", "ascii", "first-rune-only"}
+
+// Folds are the case foldings by name.
+var Folds = map[string]func(a, b string) bool{
+	"unicode":         strings.EqualFold,
+	"ascii":           equalFoldASCII,
+	"first-rune-only": equalFoldFirstRune,
+}
+
+// equalFoldASCII is strings.EqualFold for only the ASCII letters.
+// Any other runes must be the same.
+func equalFoldASCII(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	lower := func(c byte) byte {
+		if 'A' <= c && c <= 'Z' {
+			return c + 'a' - 'A'
+		}
+		return c
+	}
+	for i := 0; i < len(a); i++ {
+		if lower(a[i]) != lower(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalFoldFirstRune reports whether a and b are the same
+// except for the case of their first rune.
+func equalFoldFirstRune(a, b string) bool {
+	ra, na := utf8.DecodeRuneInString(a)
+	rb, nb := utf8.DecodeRuneInString(b)
+	return a[na:] == b[nb:] && strings.EqualFold(string(ra), string(rb))
+}
+
+// Fold reports whether key and name are equal under -fold.
+func Fold(key, name string) bool {
+	return Folds[foldMode.Value](key, name)
+}
+
+// foldMatcher classifies the pairs counted, with partial matches under -fold.
+var foldMatcher = structlit.FoldMatcher(Fold)
+
+// countFolds counts m in c.Folds under each folding
+// if it could be a partial match.
+func (c *Count) countFolds(m *structlit.Match) {
+	if m.Identical || m.Selector {
+		return
+	}
+	if c.Folds == nil {
+		c.Folds = map[string]uint64{}
+	}
+	for _, f := range FoldNames {
+		if _, ok := c.Folds[f]; !ok {
+			c.Folds[f] = 0
+		}
+		if Folds[f](m.Key, m.Name) {
+			c.Folds[f]++
+		}
+	}
+}
+
+// formatFolds lists c.Folds in the order of FoldNames.
+func (c *Count) formatFolds() string {
+	var s []string
+	for _, f := range FoldNames {
+		if n, ok := c.Folds[f]; ok {
+			s = append(s, fmt.Sprintf("%s %d", f, n))
+		}
+	}
+	return strings.Join(s, ", ")
+}
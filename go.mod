@@ -1,10 +1,13 @@
 module github.com/jimmyfrasche/issue57949
 
-go 1.20
+go 1.22.0
 
-require golang.org/x/tools v0.5.0
+require (
+	github.com/golangci/plugin-module-register v0.1.1
+	golang.org/x/tools v0.26.0
+)
 
 require (
-	golang.org/x/mod v0.7.0 // indirect
-	golang.org/x/sys v0.4.0 // indirect
+	golang.org/x/mod v0.21.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
 )
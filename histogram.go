@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+)
+
+// histogramWidth is the number of characters in the longest bar.
+const histogramWidth = 40
+
+// writeHistogram writes title then a bar of # for each label,
+// scaled so the largest count is histogramWidth long.
+// A count that is not zero always gets at least one #.
+func writeHistogram(w io.Writer, title string, labels []string, counts []uint64) {
+	var max uint64
+	lw, cw := 0, 0
+	for i, n := range counts {
+		if n > max {
+			max = n
+		}
+		if len(labels[i]) > lw {
+			lw = len(labels[i])
+		}
+		if d := len(strconv.FormatUint(n, 10)); d > cw {
+			cw = d
+		}
+	}
+	fmt.Fprintf(w, "%s:\n", title)
+	for i, n := range counts {
+		bar := 0
+		if max > 0 {
+			bar = int(n * histogramWidth / max)
+		}
+		if bar == 0 && n > 0 {
+			bar = 1
+		}
+		line := fmt.Sprintf("  %-*s %*d %s", lw, labels[i], cw, n, strings.Repeat("#", bar))
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
+
+// writeKeyLengths writes the histogram of c.KeyLengths, shortest first.
+func (c *Count) writeKeyLengths(w io.Writer) {
+	if len(c.KeyLengths) == 0 {
+		return
+	}
+	var lens []int
+	for l := range c.KeyLengths {
+		lens = append(lens, l)
+	}
+	sort.Ints(lens)
+	var labels []string
+	var counts []uint64
+	for _, l := range lens {
+		labels = append(labels, strconv.Itoa(l))
+		counts = append(counts, c.KeyLengths[l])
+	}
+	writeHistogram(w, "exact matches by key length", labels, counts)
+}
+
+// countRuns counts in c.Runs each run of consecutive exact matches in ms,
+// the matches of the pairs of a literal in order.
+func (c *Count) countRuns(ms []*structlit.Match) {
+	run := 0
+	for i := 0; i <= len(ms); i++ {
+		if i < len(ms) && ms[i] != nil && ms[i].Identical {
+			run++
+			continue
+		}
+		if run > 0 {
+			if c.Runs == nil {
+				c.Runs = map[int]uint64{}
+			}
+			c.Runs[run]++
+		}
+		run = 0
+	}
+}
+
+// writeRuns writes the histogram of c.Runs, bucketed by sizeBuckets.
+func (c *Count) writeRuns(w io.Writer) {
+	if len(c.Runs) == 0 {
+		return
+	}
+	counts := make([]uint64, len(sizeBuckets)+1)
+	for n, k := range c.Runs {
+		counts[sizeBucket(n)] += k
+	}
+	writeHistogram(w, "runs of consecutive exact matches by length", sizeLabels(), counts)
+}
+
+// sizeBuckets are the upper bounds of the buckets of the literal size histogram.
+// The last bucket has no upper bound.
+var sizeBuckets = []int{1, 2, 3, 4, 8, 16}
+
+// sizeLabels are the names of the buckets of sizeBuckets, such as 5-8.
+func sizeLabels() []string {
+	labels := make([]string, len(sizeBuckets)+1)
+	lo := 1
+	for i, hi := range sizeBuckets {
+		labels[i] = strconv.Itoa(hi)
+		if hi > lo {
+			labels[i] = fmt.Sprintf("%d-%d", lo, hi)
+		}
+		lo = hi + 1
+	}
+	labels[len(sizeBuckets)] = fmt.Sprintf("%d+", lo)
+	return labels
+}
+
+// sizeBucket is the index of the bucket of sizeBuckets n is in.
+func sizeBucket(n int) int {
+	return sort.SearchInts(sizeBuckets, n)
+}
+
+// writeSizes writes the histogram of c.Sizes,
+// with the larger sizes bucketed by sizeBuckets.
+func (c *Count) writeSizes(w io.Writer) {
+	if len(c.Sizes) == 0 {
+		return
+	}
+	counts := make([]uint64, len(sizeBuckets)+1)
+	for n, k := range c.Sizes {
+		counts[sizeBucket(n)] += k
+	}
+	writeHistogram(w, "literals by KV pairs", sizeLabels(), counts)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+)
+
+// countLengths records the lengths of the key and value of the candidate pair m
+// in c.PairLengths and whether the value is shorter or longer than the key,
+// as in Address: addr.
+func (c *Count) countLengths(m *structlit.Match) {
+	k, v := len(m.Key), len(m.Name)
+	if c.PairLengths == nil {
+		c.PairLengths = map[string]uint64{}
+	}
+	c.PairLengths[strconv.Itoa(k)+","+strconv.Itoa(v)]++
+	switch {
+	case v < k:
+		c.ValueShorter++
+	case v > k:
+		c.ValueLonger++
+	}
+}
+
+// pairLengths splits a key of PairLengths into the key and value lengths.
+func pairLengths(s string) (k, v int) {
+	ks, vs, _ := strings.Cut(s, ",")
+	k, _ = strconv.Atoi(ks)
+	v, _ = strconv.Atoi(vs)
+	return k, v
+}
+
+// writePairLengths writes the candidate pairs by the length of their key, down,
+// and of their value, across, bucketed by sizeBuckets.
+func (c *Count) writePairLengths(w io.Writer) {
+	if len(c.PairLengths) == 0 {
+		return
+	}
+	labels := sizeLabels()
+	grid := make([][]uint64, len(labels))
+	for i := range grid {
+		grid[i] = make([]uint64, len(labels))
+	}
+	var total uint64
+	for s, n := range c.PairLengths {
+		k, v := pairLengths(s)
+		grid[sizeBucket(k)][sizeBucket(v)] += n
+		total += n
+	}
+	fmt.Fprintf(w, "candidate pairs by key and value length: value shorter %d, same %d, longer %d\n", c.ValueShorter, total-c.ValueShorter-c.ValueLonger, c.ValueLonger)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "key \\ value\t%s\t\n", strings.Join(labels, "\t"))
+	for i, row := range grid {
+		fmt.Fprintf(tw, "%s\t", labels[i])
+		for _, n := range row {
+			fmt.Fprintf(tw, "%d\t", n)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
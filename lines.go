@@ -0,0 +1,31 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// CodeLines returns the number of lines of f that are not blank
+// or only comments: those that a node other than a comment starts or ends on.
+// The insides of multi-line raw strings are not counted.
+func CodeLines(fset *token.FileSet, f *ast.File) uint64 {
+	tf := fset.File(f.Pos())
+	if tf == nil {
+		return 0
+	}
+	lines := map[int]bool{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil:
+			return false
+		case *ast.CommentGroup, *ast.Comment:
+			return false
+		}
+		lines[tf.Line(n.Pos())] = true
+		if n.End() > n.Pos() {
+			lines[tf.Line(n.End()-1)] = true
+		}
+		return true
+	})
+	return uint64(len(lines))
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	overlay = loadFlags.String("overlay", "", "read `file`, a JSON overlay in the format of go build -overlay, to replace file contents when loading")
+	chdir   = loadFlags.String("C", "", "load packages as if run in `dir`, like go -C")
+	tags    = loadFlags.String("tags", "", "comma-separated list of build `tags` to consider satisfied, like go build -tags")
+	goos    = loadFlags.String("goos", "", "load packages for GOOS `os`")
+	goarch  = loadFlags.String("goarch", "", "load packages for GOARCH `arch`")
+	tests   = loadFlags.Bool("test", false, "also load the test variants of the packages and their external tests, like go vet")
+	modMode = NewEnum(loadFlags, "mod", "", "the module download mode, like go build -mod, overriding any -mod in GOFLAGS; by default the go command uses vendor when there is a vendor directory", "readonly", "vendor", "mod")
+	env     EnvVars
+)
+
+func init() {
+	loadFlags.Var(&env, "env", "set `K=V` in the environment of the go command (may be repeated)")
+}
+
+// EnvVars is a flag.Value of repeated K=V environment variables.
+type EnvVars []string
+
+func (e *EnvVars) String() string {
+	return strings.Join(*e, " ")
+}
+
+func (e *EnvVars) Set(s string) error {
+	if k, _, ok := strings.Cut(s, "="); !ok || k == "" {
+		return fmt.Errorf("%q is not K=V", s)
+	}
+	*e = append(*e, s)
+	return nil
+}
+
+var logModOnce sync.Once
+
+// GoflagsMod returns the value of any -mod flag in the GOFLAGS of env,
+// or of this process if env is nil, or "" if there is none.
+// The go command itself reads GOFLAGS, so this is only to report it.
+func GoflagsMod(env []string) string {
+	if env == nil {
+		env = os.Environ()
+	}
+	var goflags string
+	// the last one is the one used
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "GOFLAGS="); ok {
+			goflags = v
+		}
+	}
+	mod := ""
+	for _, f := range strings.Fields(goflags) {
+		// -mod=x or --mod=x
+		f = strings.TrimPrefix(strings.TrimPrefix(f, "-"), "-")
+		if v, ok := strings.CutPrefix(f, "mod="); ok {
+			mod = v
+		}
+	}
+	return mod
+}
+
+// LoadEnv returns the environment for the go command,
+// or nil to use that of this process unchanged.
+func LoadEnv() []string {
+	var extra []string
+	if *goos != "" {
+		extra = append(extra, "GOOS="+*goos)
+	}
+	if *goarch != "" {
+		extra = append(extra, "GOARCH="+*goarch)
+	}
+	// -env is last so it has the final say
+	extra = append(extra, env...)
+	if len(extra) == 0 {
+		return nil
+	}
+	return append(os.Environ(), extra...)
+}
+
+// NewConfig returns the packages.Config for loading with mode from dir,
+// or the -C directory if dir is empty,
+// populated from the flags that control loading.
+func NewConfig(ctx context.Context, dir string, mode packages.LoadMode) (*packages.Config, error) {
+	if dir == "" {
+		dir = *chdir
+	}
+	cfg := &packages.Config{
+		Mode:    mode,
+		Context: ctx,
+		Dir:     dir,
+		Env:     LoadEnv(),
+		Tests:   *tests,
+	}
+	if *tags != "" {
+		cfg.BuildFlags = append(cfg.BuildFlags, "-tags="+*tags)
+	}
+	if modMode.Value != "" {
+		cfg.BuildFlags = append(cfg.BuildFlags, "-mod="+modMode.Value)
+	}
+	logModOnce.Do(func() {
+		if m := GoflagsMod(cfg.Env); m != "" && modMode.Value != "" && m != modMode.Value {
+			log.Printf("-mod=%s overrides -mod=%s in GOFLAGS", modMode.Value, m)
+		}
+	})
+	if *overlay != "" {
+		o, err := ReadOverlay(*overlay, dir)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Overlay = o
+	}
+	return cfg, nil
+}
+
+// ReadOverlay reads an overlay file as used by go build -overlay and gopls:
+//
+//	{"Replace": {"/path/to/file.go": "/path/to/replacement.go"}}
+//
+// and returns the contents of each replacement keyed by the file it replaces,
+// as packages.Config.Overlay expects.
+// Relative paths to replaced files are relative to dir,
+// or the working directory if dir is empty,
+// and relative paths to replacements are relative to the working directory.
+func ReadOverlay(name, dir string) (map[string][]byte, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var o struct {
+		Replace map[string]string
+	}
+	if err := json.Unmarshal(bs, &o); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	m := map[string][]byte{}
+	for file, repl := range o.Replace {
+		if repl == "" {
+			return nil, fmt.Errorf("%s: %s: deleting files is not supported", name, file)
+		}
+		src, err := os.ReadFile(repl)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if dir != "" && !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		m[abs] = src
+	}
+	return m, nil
+}
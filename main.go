@@ -2,170 +2,951 @@ package main
 
 import (
 	"context"
-	"flag"
+	"errors"
 	"fmt"
 	"go/ast"
-	"go/token"
 	"go/types"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"runtime/trace"
 	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/jimmyfrasche/issue57949/structlit"
+	"golang.org/x/tools/go/analysis/unitchecker"
 	"golang.org/x/tools/go/packages"
 )
 
+var (
+	stream   = countFlags.Bool("stream", false, "print each package as soon as it is counted, unsorted")
+	cache    = countFlags.Bool("cache", false, "reuse and record results for packages in versioned modules")
+	cacheDir = countFlags.String("cache-dir", "", "directory for -cache (default: the user cache directory)")
+	patterns = loadFlags.String("patterns", "", "read additional package patterns from `file`, one per line")
+	batch    = loadFlags.Int("batch", 1000, "load at most `n` patterns at a time (0 for no limit)")
+)
+
 func main() {
 	log.SetFlags(0)
-	flag.Parse()
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer stop()
+	// count is the default so plain flags and patterns work as they always have
+	args := os.Args[1:]
+	if IsVetTool(args) {
+		unitchecker.Main(structlit.Analyzer)
+	}
+	cmd := commands[0]
+	if len(args) > 0 {
+		if args[0] == "help" {
+			Help(args[1:])
+			return
+		}
+		if c := lookupCommand(args[0]); c != nil {
+			cmd, args = c, args[1:]
+		}
+	}
+	fs := cmd.FlagSet()
+	fs.Parse(args)
+	if err := ApplyConfig(fs); err != nil {
+		log.Fatal(err)
+	}
+	if err := ValidateFlags(fs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintf(os.Stderr, "run 'issue57949 help %s' for usage\n", cmd.Name)
+		os.Exit(2)
+	}
+	SetInvocation(cmd.Name, fs)
+
+	if *metrics {
+		for _, name := range MetricNames() {
+			fmt.Println(name)
+		}
+		return
+	}
 
-	err := Main(ctx, flag.Args())
+	stopProfiling, err := StartProfiling()
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	var (
+		w   io.Writer = os.Stdout
+		out *AtomicFile
+	)
+	if *outFile != "" {
+		name, err := ExpandOutput(*outFile, time.Now())
+		if err != nil {
+			log.Fatal(err)
+		}
+		out, err = CreateAtomic(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w = out
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err = cmd.Run(ctx, w, fs.Args())
+	if err == nil {
+		err = WriteMeta()
+	}
+	stop()
+	stopProfiling()
+	if *showStats {
+		if serr := WriteStats(os.Stderr); serr != nil {
+			log.Println(serr)
+		}
+	}
+	if out != nil {
+		// only replace the file with a complete report
+		var ge *GateError
+		if err != nil && !errors.As(err, &ge) {
+			out.Abort()
+		} else if cerr := out.Commit(); cerr != nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		var le *LoadError
+		if errors.As(err, &le) {
+			if werr := le.Write(os.Stderr); werr != nil {
+				log.Println(werr)
+			}
+			// the JSON is the whole story
+			if errorFormat.Value == "json" {
+				os.Exit(1)
+			}
+		}
+		log.Fatal(err)
+	}
 }
 
-func Main(ctx context.Context, args []string) error {
-	ps, err := GetPackages(ctx, args)
+// Main runs the count command.
+func Main(ctx context.Context, w io.Writer, args []string) error {
+	args, err := Patterns(ctx, args)
 	if err != nil {
 		return err
 	}
 
+	if sample, err = NewSample(); err != nil {
+		return err
+	}
+	if baseline, err = LoadBaseline(); err != nil {
+		return err
+	}
+	if err := alerts.NeedSplits(); err != nil {
+		return err
+	}
+
+	var (
+		rc     *Cache
+		cached []*Count
+	)
+	if *cache && (*findDuplicates || *dedupe || annotations() || document() || siteRecords() || sample != nil) {
+		log.Println("-duplicates, -dedupe, -out=gh-annotations, -out=document, -sites, and sampling need every package counted: not using cache")
+	} else if *cache && *tests {
+		log.Println("-test loads test variants that cannot be loaded again by path: not using cache")
+	} else if *cache && !*stdin {
+		var err error
+		rc, err = OpenCache(*cacheDir, CacheSalt())
+		if err != nil {
+			return err
+		}
+		if rc == nil {
+			log.Println("tool version unknown: not using cache")
+		} else {
+			cached, args, err = rc.Lookup(ctx, args)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var (
+		ps      []*packages.Package
+		skipped []PackageError
+		// with -max-memory, guard loads paths a batch at a time while counting
+		guard = NewMemoryGuard()
+		paths []string
+	)
+	switch {
+	case *stdin:
+		if len(args) > 0 {
+			return errors.New("-stdin does not take package patterns")
+		}
+		p, err := ReadFilePackage(os.Stdin, *stdinFilename)
+		if err != nil {
+			return err
+		}
+		ps = append(ps, p)
+	// everything may have come from the cache
+	case guard != nil && (rc == nil || len(args) > 0):
+		if paths, err = guard.List(ctx, args); err != nil {
+			return err
+		}
+	case rc == nil || len(args) > 0:
+		var err error
+		ps, skipped, err = GetPackages(ctx, "", args)
+		if err != nil {
+			return err
+		}
+		ps = sample.SamplePackages(ps)
+	}
+
+	// the first error writing the sites or counts as they are counted,
+	// which stops the counting
+	var werr error
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	wrote := func(err error) {
+		if err != nil && werr == nil {
+			werr = err
+			stop()
+		}
+	}
+
 	total := New("<total>")
 	counts := []*Count{}
-	for _, p := range ps {
-		c := CountPackage(p)
+	// the IDs written by -stream
+	streamed := map[string]bool{total.ID: true}
+	add := func(c *Count) {
 		total.Add(c)
+		if *stream {
+			wrote(writeCount(w, c))
+			streamed[c.ID] = true
+			return
+		}
 		counts = append(counts, c)
 	}
+	for _, c := range cached {
+		add(c)
+	}
+	// per module subtotals for -workspace
+	modules := map[string]*Count{}
+	var (
+		sites *siteWriter
+		// every site, for -out=document
+		all []*Site
+	)
+	if siteRecords() {
+		if sites, err = newSiteWriter(w); err != nil {
+			return err
+		}
+	}
+	countPackage := func(p *packages.Package) {
+		var c *Count
+		switch {
+		case annotations():
+			c = CountPackageFunc(ctx, p, func(s *Site) {
+				wrote(WriteAnnotation(w, s))
+			})
+		case siteRecords():
+			c = CountPackageFunc(ctx, p, func(s *Site) {
+				wrote(sites.Write(s))
+			})
+		case document():
+			c = CountPackageFunc(ctx, p, func(s *Site) {
+				all = append(all, s)
+			})
+		default:
+			c = CountPackage(ctx, p)
+		}
+		// don't record a package cut short
+		// or one missing files counted by another package
+		if rc != nil && ctx.Err() == nil && c.Collapsed == 0 {
+			if err := rc.Put(p, c); err != nil {
+				log.Println(err)
+			}
+		}
+		if *workspace && p.Module != nil {
+			m, ok := modules[p.Module.Path]
+			if !ok {
+				m = New("<module " + p.Module.Path + ">")
+				modules[p.Module.Path] = m
+			}
+			m.Add(c)
+		}
+		add(c)
+	}
+	if paths != nil {
+		if ps, skipped, err = guard.Each(ctx, paths, countPackage); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range ps {
+			if ctx.Err() != nil {
+				break
+			}
+			countPackage(p)
+		}
+	}
+	if werr != nil {
+		return werr
+	}
 
-	sort.Slice(counts, func(i, j int) bool {
-		return counts[i].ID < counts[j].ID
-	})
-	// don't include total if there's only one package
-	if len(counts) > 1 {
-		counts = append(counts, total)
+	if groups := SamePath(ps); len(groups) > 0 {
+		LogSamePath(groups)
+		if samePath.Value == "merge" {
+			counts = MergeSamePath(counts, groups)
+		}
+	}
+
+	var subtotals []*Count
+	for _, m := range modules {
+		subtotals = append(subtotals, m)
+	}
+	SortCounts(subtotals)
+
+	if annotations() || siteRecords() {
+		LogSkipped(skipped)
+		if sites != nil {
+			if err := sites.Flush(); err != nil {
+				return err
+			}
+		}
+		return finish(ctx, total)
+	}
+
+	if *stream {
+		for _, c := range subtotals {
+			if err := writeCount(w, c); err != nil {
+				return err
+			}
+			streamed[c.ID] = true
+		}
+		if showTotal(len(cached)+len(ps) > 1) {
+			if err := writeCount(w, total); err != nil {
+				return err
+			}
+		}
+		if err := writeSkipped(w, skipped); err != nil {
+			return err
+		}
+		if baseline != nil {
+			if err := writeRemoved(w, baseline.Removed(streamed)); err != nil {
+				return err
+			}
+		}
+		if err := writeSample(w, sample); err != nil {
+			return err
+		}
+		return finish(ctx, total)
 	}
 
-	for _, c := range counts {
-		fmt.Println(c)
+	SortCounts(counts)
+
+	defer trace.StartRegion(ctx, "format").End()
+	r := &Report{Packages: counts, Modules: subtotals, Total: total, Skipped: skipped, Sample: sample}
+	if baseline != nil {
+		r.Baseline = baseline.Compare(append(append(counts[:len(counts):len(counts)], subtotals...), total))
+	}
+	if document() {
+		if err := writeDocument(w, r, all); err != nil {
+			return err
+		}
+		return finish(ctx, total)
+	}
+	if err := r.Write(w); err != nil {
+		return err
 	}
-	return nil
+	if *watch && ctx.Err() == nil {
+		if *debugAddr != "" {
+			if err := ServeDebug(ctx, *debugAddr); err != nil {
+				return err
+			}
+		}
+		return Watch(ctx, w, ps, counts)
+	}
+	return finish(ctx, total)
+}
+
+// Patterns returns the package patterns from args and the flags.
+func Patterns(ctx context.Context, args []string) ([]string, error) {
+	if *patterns != "" {
+		more, err := ReadPatterns(*patterns)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, more...)
+	}
+	if *workspace {
+		if len(args) > 0 {
+			return nil, errors.New("-workspace does not take package patterns")
+		}
+		return WorkspacePatterns(ctx, *chdir)
+	}
+	return args, nil
 }
 
-func GetPackages(ctx context.Context, pattern []string) ([]*packages.Package, error) {
-	cfg := &packages.Config{
-		// does not count as RHS is expression
-		Mode: packages.NeedTypesInfo | packages.NeedTypes | packages.NeedSyntax | packages.NeedFiles,
-		// counts as simple ident but neither exact nor partial match
-		Context: ctx,
+// finish reports an interruption after any partial results
+// or else whether total passes -fail-if and -max-partial.
+func finish(ctx context.Context, total *Count) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
-	ps, err := packages.Load(cfg, pattern...)
+	conds := failIf
+	if *maxPartial >= 0 {
+		conds = append(conds, Condition{Metric: "partial", Op: ">", Value: float64(*maxPartial)})
+	}
+	return Check(total, conds)
+}
+
+// GetPackages loads the packages matching pattern from dir,
+// or the -C directory if dir is empty.
+//
+// With -errors=report, packages with errors are returned as skipped
+// instead of failing and, with -errors=skip, their errors are logged
+// and they are left out.
+func GetPackages(ctx context.Context, dir string, pattern []string) (ps []*packages.Package, skipped []PackageError, err error) {
+	defer trace.StartRegion(ctx, "load").End()
+	cfg, err := NewConfig(ctx, dir, packages.NeedTypesInfo|packages.NeedTypes|packages.NeedSyntax|packages.NeedFiles|packages.NeedName|packages.NeedModule|packages.NeedImports|packages.NeedDeps)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	if packages.PrintErrors(ps) > 0 {
-		return nil, fmt.Errorf("could not load packages")
+	ps, err = LoadBatched(cfg, pattern)
+	if err != nil {
+		loadErrors.Add(1)
+		return nil, nil, err
+	}
+	if *tests {
+		ps = dropTestMains(ps)
+	}
+	if *allowTypeErrors {
+		DropTypeErrors(ps)
+	}
+	if ErrorPolicy() == "fail" {
+		if err := LoadErrors(ps); err != nil {
+			loadErrors.Add(int64(len(err.(*LoadError).Errors)))
+			return nil, nil, err
+		}
+	} else {
+		ps, skipped = SkipErrors(ps)
+		loadErrors.Add(int64(len(skipped)))
 	}
-	if len(ps) == 0 {
-		return nil, fmt.Errorf("no packages to load")
+	if len(ps) == 0 && len(skipped) == 0 {
+		return nil, nil, fmt.Errorf("no packages to load")
+	}
+	if ErrorPolicy() == "skip" {
+		LogSkipped(skipped)
+		skipped = nil
+	}
+	return ps, skipped, nil
+}
+
+// dropTestMains removes the generated main packages of test binaries,
+// such as fx/a.test, which have no files of their own.
+func dropTestMains(ps []*packages.Package) []*packages.Package {
+	var keep []*packages.Package
+	for _, p := range ps {
+		if !strings.HasSuffix(p.ID, ".test") {
+			keep = append(keep, p)
+		}
+	}
+	return keep
+}
+
+// LoadBatched calls packages.Load with at most -batch patterns at a time
+// to stay within the limits of go list, dropping any package
+// already returned by an earlier batch.
+func LoadBatched(cfg *packages.Config, pattern []string) ([]*packages.Package, error) {
+	n := *batch
+	if n <= 0 || len(pattern) <= n {
+		defer timeLoad(time.Now())
+		return packages.Load(cfg, pattern...)
+	}
+	var ps []*packages.Package
+	seen := map[string]bool{}
+	for len(pattern) > 0 {
+		if n > len(pattern) {
+			n = len(pattern)
+		}
+		start := time.Now()
+		b, err := packages.Load(cfg, pattern[:n]...)
+		timeLoad(start)
+		if err != nil {
+			return nil, err
+		}
+		pattern = pattern[n:]
+		for _, p := range b {
+			if !seen[p.ID] {
+				seen[p.ID] = true
+				ps = append(ps, p)
+			}
+		}
+	}
+	return ps, nil
+}
+
+// ReadPatterns reads package patterns from a file, one per line.
+// Blank lines and lines starting with # are ignored.
+func ReadPatterns(name string) ([]string, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var ps []string
+	for _, line := range strings.Split(string(bs), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ps = append(ps, line)
 	}
 	return ps, nil
 }
 
-func CountPackage(p *packages.Package) *Count {
+func CountPackage(ctx context.Context, p *packages.Package) *Count {
+	return CountPackageFunc(ctx, p, nil)
+}
+
+// CountPackageFunc is CountPackage but also calls visit, if not nil,
+// with each KV pair counted.
+func CountPackageFunc(ctx context.Context, p *packages.Package, visit func(*Site)) *Count {
+	defer trace.StartRegion(ctx, "count").End()
+	defer timeCount(p, time.Now())
 	count := New(p.ID)
+	// such as a package only available as export data,
+	// which must not look like one without literals
+	if len(p.Syntax) == 0 {
+		count.NoSource++
+		return count
+	}
+	cgo := UsesCgo(p)
+	if cgo {
+		count.Cgo++
+		if cgoMode.Value == "skip" {
+			return count
+		}
+	}
 	for _, f := range p.Syntax {
-		ast.Inspect(f, func(n ast.Node) bool {
-			if c, ok := n.(*ast.CompositeLit); ok {
-				// only care if composite lit of a struct type
-				typ := p.TypesInfo.Types[c].Type
-				if _, ok := typ.Underlying().(*types.Struct); ok {
-					keyed := false
-					for _, x := range c.Elts {
-						// only care if keyed
-						if kv, ok := x.(*ast.KeyValueExpr); ok {
-							keyed = true
-							count.Count(MatchOf(kv))
-						}
-					}
-					if keyed {
-						count.Literals++
-					}
+		if ctx.Err() != nil {
+			break
+		}
+		if excludeFiles.Match(p.Fset.Position(f.Package).Filename) {
+			continue
+		}
+		if cgo && cgoMode.Value != "generated" && IsCgoGenerated(p, f) {
+			continue
+		}
+		if !sample.keepFile(p.ID, p.Fset.Position(f.Package).Filename) {
+			continue
+		}
+		if CountedElsewhere(p.ID, p.Fset.Position(f.Package).Filename) {
+			count.Collapsed++
+			continue
+		}
+		if *countTypes {
+			CountTypes(count, p, f)
+		}
+		CountAssignments(count, p, f)
+		CountOptionCalls(count, p, f)
+		count.Lines += CodeLines(p.Fset, f)
+		if *allowTypeErrors {
+			count.Untyped += uint64(Untyped(p.TypesInfo, f))
+		}
+		skipped := Skipped(f)
+		build := BuildConstraint(f)
+		structlit.StructLits(p.TypesInfo, f, func(path []ast.Node, c *ast.CompositeLit, typ types.Type, kvs []*ast.KeyValueExpr) {
+			if skipped(c.Pos()) || !CountedType(typ) {
+				return
+			}
+			if (*findDuplicates || *dedupe) && Duplicate(p, c, typ) {
+				count.Duplicates++
+				if *dedupe {
+					return
+				}
+			}
+			ms := count.countLiteral(p, c, kvs)
+			count.countRecent(p.TypesInfo, path, kvs, ms)
+			if build != "" {
+				if count.Constraints == nil {
+					count.Constraints = map[string]uint64{}
+				}
+				count.Constraints[build]++
+			}
+			for _, name := range splitBy {
+				count.bucket(name, Splits[name].Bucket(p, f, path, c)).countLiteral(p, c, kvs)
+			}
+			if visit != nil {
+				for i, kv := range kvs {
+					visit(NewSite(p, c, kv, typ, ms[i]))
 				}
 			}
-			return true
 		})
 	}
+	packagesAnalyzed.Add(1)
+	literalsCounted.Add(int64(count.Literals))
 	return count
 }
 
-type Match struct {
-	Identical, Partial           bool
-	Regular, Star, Amp, Selector bool
+// countLiteral counts the literal c with the KV pairs kvs from p
+// and returns the match of each pair.
+func (count *Count) countLiteral(p *packages.Package, c *ast.CompositeLit, kvs []*ast.KeyValueExpr) []*structlit.Match {
+	count.Literals++
+	// the type is elided, as in []T{{A: a}},
+	// but still recorded in the types info
+	if c.Type == nil {
+		count.Implicit++
+	}
+	if isGeneric(structlit.LitType(p.TypesInfo, c)) {
+		count.Generic++
+	}
+	if IsAlias(p.TypesInfo, c) {
+		count.Alias++
+	}
+	// an anonymous struct type, as in struct{ A int }{A: a}
+	_, anonymous := structlit.LitType(p.TypesInfo, c).(*types.Struct)
+	if anonymous {
+		count.Anonymous++
+	}
+	if IsMultiLine(p.Fset, c) {
+		count.MultiLine++
+	}
+	if IsOptionsStruct(structlit.LitType(p.TypesInfo, c)) {
+		count.Options++
+	}
+	exact := 0
+	ms := make([]*structlit.Match, len(kvs))
+	for i, kv := range kvs {
+		if _, ok := kv.Key.(*ast.Ident); !ok {
+			count.KV++
+			count.BadKey++
+			continue
+		}
+		count.countNested(p.TypesInfo, kv)
+		m := structlit.MatchOf(kv, foldMatcher, p.TypesInfo)
+		if m != nil && m.Selector {
+			m.Package = isPackageQualified(p.TypesInfo, kv.Value)
+		}
+		if m != nil && !m.Selector && isDotImported(p, kv.Value) {
+			// it is really pkg.Name
+			m.Selector, m.Regular, m.Partial, m.Package = true, false, false, true
+			count.DotImport++
+		}
+		ms[i] = m
+		count.Count(m)
+		if m != nil {
+			count.countFolds(m)
+			count.countLengths(m)
+			if anonymous {
+				count.AnonymousPairs.Count(m.Identical, m.Partial)
+			}
+		}
+		count.scoreRules(kv, p.TypesInfo, matchRules)
+		if m != nil && m.Identical {
+			exact++
+			count.countObject(valueObject(p.TypesInfo, kv.Value))
+			if *topFields > 0 {
+				count.countField(structlit.LitType(p.TypesInfo, c).String(), m.Key)
+			}
+		}
+		if Hazard(p.Types, p.TypesInfo, kv) {
+			count.Hazard++
+		}
+	}
+	count.ExactFraction[fractionBucket(exact, len(kvs))]++
+	count.countRuns(ms)
+	if *topTypes > 0 {
+		count.countTypeTally(structlit.LitType(p.TypesInfo, c).String(), len(kvs), exact)
+	}
+	count.countReflow(p.Fset, c, ms)
+	if count.Sizes == nil {
+		count.Sizes = map[int]uint64{}
+	}
+	count.Sizes[len(kvs)]++
+	switch {
+	case exact == len(kvs):
+		count.AllExact++
+	case exact == len(kvs)-1 && exact > 0:
+		count.AllButOne++
+	}
+	return ms
 }
 
-func MatchOf(kv *ast.KeyValueExpr) *Match {
-	var Star, Amp bool
-	ident, Selector := GetIdentFrom(kv.Value)
+// FractionBuckets name the buckets of Count.ExactFraction in percent.
+var FractionBuckets = []string{"0", "1-25", "26-50", "51-75", "76-99", "100"}
 
-	// if these fire ident was nil anyway
-	switch v := kv.Value.(type) {
-	case *ast.StarExpr:
-		// only count *name
-		ident, Selector = GetIdentFrom(v.X)
-		Star = true
-	case *ast.UnaryExpr:
-		// only count &name
-		if v.Op == token.AND {
-			ident, Selector = GetIdentFrom(v.X)
-			Amp = true
+// fractionBucket returns the bucket of exact out of n in FractionBuckets.
+func fractionBucket(exact, n int) int {
+	switch exact {
+	case 0:
+		return 0
+	case n:
+		return 5
+	}
+	// rounds up so 1 to 25% is bucket 1
+	return (exact*4 + n - 1) / n
+}
+
+// Untyped returns the number of keyed literals in f
+// that are skipped because their type is unknown
+// and could not be resolved by LitType.
+// Map, slice, and array literals are not included when that is evident from their syntax.
+func Untyped(info *types.Info, f *ast.File) int {
+	skipped := 0
+	ast.Inspect(f, func(n ast.Node) bool {
+		c, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		if typ := info.Types[c].Type; typ != nil && typ != types.Typ[types.Invalid] {
+			return true
 		}
+		switch c.Type.(type) {
+		case *ast.MapType, *ast.ArrayType:
+			return true
+		}
+		if structlit.LitType(info, c) != nil {
+			return true
+		}
+		for _, x := range c.Elts {
+			if _, ok := x.(*ast.KeyValueExpr); ok {
+				skipped++
+				break
+			}
+		}
+		return true
+	})
+	return skipped
+}
+
+// IsAlias reports whether the type of c is written as an alias,
+// as in A{X: x} after type A = T.
+// The type of c is already the aliased type.
+func IsAlias(info *types.Info, c *ast.CompositeLit) bool {
+	var id *ast.Ident
+	switch t := c.Type.(type) {
+	case *ast.Ident:
+		id = t
+	case *ast.SelectorExpr:
+		id = t.Sel
+	default:
+		return false
 	}
-	if ident == nil {
-		return nil
+	tn, ok := info.Uses[id].(*types.TypeName)
+	return ok && tn.IsAlias()
+}
+
+// isGeneric reports whether typ is a type parameter
+// or an instantiation of a generic type.
+func isGeneric(typ types.Type) bool {
+	switch t := typ.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Named:
+		return t.TypeArgs().Len() > 0
 	}
+	return false
+}
 
-	key := kv.Key.(*ast.Ident).Name
-	name := ident.Name
+// Hazard reports whether the key of kv, written bare at kv
+// as a shorthand would have it, refers to something in scope
+// other than the value, such as Name in Name: n.Name when a Name is declared.
+func Hazard(pkg *types.Package, info *types.Info, kv *ast.KeyValueExpr) bool {
+	key := kv.Key.(*ast.Ident)
+	s := pkg.Scope().Innermost(key.Pos())
+	if s == nil {
+		return false
+	}
+	_, o := s.LookupParent(key.Name, key.Pos())
+	if o == nil {
+		return false
+	}
+	v, ok := kv.Value.(*ast.Ident)
+	return !ok || info.Uses[v] != o
+}
 
-	Identical := key == name
-	// only count partial matches when not identical and for name not name.name
-	partial := !Identical && !Selector && strings.EqualFold(key, name)
+// isPackageQualified reports whether x is pkg.Name, *pkg.Name, or &pkg.Name
+// for an imported package.
+func isPackageQualified(info *types.Info, x ast.Expr) bool {
+	switch v := x.(type) {
+	case *ast.StarExpr:
+		x = v.X
+	case *ast.UnaryExpr:
+		x = v.X
+	}
+	sel, ok := x.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, ok = info.Uses[id].(*types.PkgName)
+	return ok
+}
 
-	return &Match{
-		Regular: !Star && !Amp && !Selector,
-		// Partial is a partial match so we have one for testing
-		Partial: partial,
-		// These all count as simple idents with exact matches
-		Identical: Identical,
-		Star:      Star,
-		Amp:       Amp,
-		Selector:  Selector,
+// isDotImported reports whether x is name, *name, or &name
+// for a name from a package imported with import . "pkg".
+func isDotImported(p *packages.Package, x ast.Expr) bool {
+	id := structlit.ValueIdent(x)
+	if id == nil {
+		return false
 	}
+	o := p.TypesInfo.Uses[id]
+	// the universe has no package
+	return o != nil && o.Pkg() != nil && o.Pkg() != p.Types
 }
 
-func GetIdentFrom(n ast.Node) (ident *ast.Ident, selector bool) {
-	switch v := n.(type) {
-	case *ast.Ident:
-		ident = v
-	case *ast.SelectorExpr:
-		// only count name.name
-		if _, ok := v.X.(*ast.Ident); ok {
-			ident, selector = v.Sel, true
-		}
+// valueObject returns the object named by the identifier in x,
+// as in name, x.name, *name, or &x.name, or nil.
+func valueObject(info *types.Info, x ast.Expr) types.Object {
+	switch v := x.(type) {
+	case *ast.StarExpr:
+		x = v.X
+	case *ast.UnaryExpr:
+		x = v.X
+	}
+	id, _ := structlit.GetIdentFrom(x)
+	if id == nil {
+		return nil
+	}
+	return info.Uses[id]
+}
+
+// countObject counts o in ExactObjects if it has not been counted in c.
+func (c *Count) countObject(o types.Object) {
+	if o == nil || c.objects[o] {
+		return
+	}
+	if c.objects == nil {
+		c.objects = map[types.Object]bool{}
 	}
-	return ident, selector
+	c.objects[o] = true
+	c.ExactObjects++
 }
 
 type Count struct {
-	ID                                                            string
-	Literals, KV, NotIdent                                        uint64
-	Ident, QualifiedIdent, Star, QualifiedStar, Amp, QualifiedAmp *Tally
+	ID       string `json:"id"`
+	Literals uint64 `json:"literals"`
+	// Lines is the lines of code counted in, excluding blank and comment lines.
+	Lines uint64 `json:"lines"`
+	// Duplicates is the literals the same as another earlier in the run.
+	Duplicates uint64 `json:"duplicates"`
+	// Implicit is the literals whose type is elided, as in []T{{A: a}}.
+	Implicit uint64 `json:"implicit"`
+	// MultiLine is the literals whose braces are on different lines.
+	MultiLine uint64 `json:"multi_line"`
+	// Options is the literals of options structs, named by -options-suffixes.
+	Options uint64 `json:"options"`
+	// OptionCalls is the calls passing functional options,
+	// as in grpc.Dial(target, grpc.WithBlock()), and OptionArgs the options they pass
+	// other than as opts..., whose number is not known.
+	OptionCalls uint64 `json:"option_calls"`
+	OptionArgs  uint64 `json:"option_args"`
+	// Reflow is, by the width of ReflowWidths, the multi-line literals
+	// that would fit on one line only without the keys of their exact matches.
+	Reflow map[string]*Reflow `json:"reflow,omitempty"`
+	// Generic is the literals of type parameters or instantiated generic types.
+	Generic uint64 `json:"generic"`
+	// Alias is the literals whose type is written as an alias.
+	Alias uint64 `json:"alias"`
+	// DotImport is the pairs whose value is from a dot import,
+	// which are counted as qualified.
+	DotImport uint64 `json:"dot_import"`
+	KV        uint64 `json:"kv"`
+	NotIdent  uint64 `json:"not_ident"`
+	// NoSource is the packages with no syntax to count, only export data.
+	NoSource uint64 `json:"no_source"`
+	// Collapsed is the files not counted because they are the same file
+	// as one counted by another package through another path.
+	Collapsed uint64 `json:"collapsed"`
+	// Cgo is the packages using cgo,
+	// which are counted according to -cgo.
+	Cgo uint64 `json:"cgo"`
+	// Untyped is the keyed literals skipped for missing type information
+	// with -allow-type-errors.
+	Untyped uint64 `json:"untyped"`
+	// BadKey is the KV pairs whose key is not an identifier,
+	// which only happens with syntax errors. They are in KV but not NotIdent.
+	BadKey uint64 `json:"bad_key"`
+
+	// SavedChars and SavedTokens are how much eliding "Key: "
+	// from every exact match would remove.
+	SavedChars  uint64 `json:"saved_chars"`
+	SavedTokens uint64 `json:"saved_tokens"`
+	// Hazard is the KV pairs whose key, written bare,
+	// would refer to something other than the value.
+	Hazard uint64 `json:"hazard"`
+	// AllExact is the literals whose every KV pair is an exact match
+	// and AllButOne those with all but one, not counting single pairs.
+	AllExact  uint64 `json:"all_exact"`
+	AllButOne uint64 `json:"all_but_one"`
+	// ExactFraction is the number of literals by the percentage
+	// of their KV pairs that are exact matches, bucketed by FractionBuckets.
+	ExactFraction [6]uint64 `json:"exact_fraction"`
+	// ExactObjects is the distinct variables and other objects
+	// that are the values of exact matches.
+	// Objects are distinct per package so one used in several is counted in each.
+	ExactObjects uint64 `json:"exact_objects"`
+	objects      map[types.Object]bool
+	// CrossPackage is the exact matches whose value is
+	// from an imported package, as in Second: time.Second.
+	CrossPackage uint64 `json:"cross_package"`
+	// Rules is the number of pairs that match under each of the -rule rules.
+	Rules map[string]uint64 `json:"rules,omitempty"`
+	// RulePartials is the number of pairs that are partial matches under each rule
+	// whose Matcher has them.
+	RulePartials map[string]uint64 `json:"rule_partials,omitempty"`
+	// Folds is the pairs that would be partial matches under each -fold.
+	Folds map[string]uint64 `json:"folds,omitempty"`
+	// KeyLengths is the number of exact matches by the length of the key.
+	KeyLengths map[int]uint64 `json:"key_lengths,omitempty"`
+	// Sizes is the number of literals by their number of KV pairs.
+	Sizes map[int]uint64 `json:"sizes,omitempty"`
+	// Runs is the number of runs of consecutive exact matches
+	// within a literal by their length.
+	Runs map[int]uint64 `json:"runs,omitempty"`
+	// PairLengths is the number of candidate pairs by the lengths of their key
+	// and of their value, as "key,value", and ValueShorter and ValueLonger
+	// are the pairs whose value is shorter or longer than the key, as in Address: addr.
+	PairLengths  map[string]uint64 `json:"pair_lengths,omitempty"`
+	ValueShorter uint64            `json:"value_shorter"`
+	ValueLonger  uint64            `json:"value_longer"`
+	// Constraints is the number of literals in files with each //go:build constraint,
+	// so only counted under some configurations.
+	Constraints map[string]uint64 `json:"constraints,omitempty"`
+	// Fields is the exact matches of each type and field, as in net/http.Client.Timeout,
+	// when counted with -top.
+	Fields map[string]uint64 `json:"fields,omitempty"`
+	// Splits are the literals counted again by bucket for each -split.
+	Splits map[string]map[string]*Count `json:"splits,omitempty"`
+
+	// Types is the literals of each struct type, when counted with -types.
+	Types map[string]*TypeCount `json:"types,omitempty"`
+	// TypeTallies is the keyed literals of each struct type and their matches,
+	// when counted with -top-types.
+	TypeTallies map[string]*TypeTally `json:"type_tallies,omitempty"`
+
+	Ident          *Tally `json:"ident"`
+	QualifiedIdent *Tally `json:"qualified_ident"`
+	Star           *Tally `json:"star"`
+	QualifiedStar  *Tally `json:"qualified_star"`
+	Amp            *Tally `json:"amp"`
+	QualifiedAmp   *Tally `json:"qualified_amp"`
+
+	// Assignments is the assignments of an identifier to a field,
+	// as in x.Field = field, which are not counted in the other tallies.
+	Assignments *Tally `json:"assignments"`
+
+	// Anonymous is the literals of anonymous struct types,
+	// as in struct{ A int }{A: a}, and AnonymousPairs tallies their pairs,
+	// which are also counted in the other tallies.
+	Anonymous      uint64 `json:"anonymous"`
+	AnonymousPairs *Tally `json:"anonymous_pairs"`
+
+	// Nested is the pairs whose value is a composite literal,
+	// as in Spec: Spec{...}, which are only counted as not identifiers
+	// in the other tallies. A match is between the key and the name of the literal's type.
+	Nested *Tally `json:"nested"`
+
+	// Recent is the candidate pairs whose value is a variable declared
+	// in the -recent statements before the literal, in the same block.
+	Recent *Tally `json:"recent"`
 }
 
 func New(ID string) *Count {
@@ -179,10 +960,14 @@ func New(ID string) *Count {
 		QualifiedStar:  &Tally{},
 		Amp:            &Tally{},
 		QualifiedAmp:   &Tally{},
+		Assignments:    &Tally{},
+		AnonymousPairs: &Tally{},
+		Nested:         &Tally{},
+		Recent:         &Tally{},
 	}
 }
 
-func (c *Count) Count(m *Match) {
+func (c *Count) Count(m *structlit.Match) {
 	// inc total KV pairs
 	c.KV++
 	if m == nil {
@@ -211,55 +996,360 @@ func (c *Count) Count(m *Match) {
 		t = c.QualifiedIdent
 	}
 	t.Count(m.Identical, m.Partial)
+	if m.Identical {
+		// the key, colon, and space
+		c.SavedChars += uint64(len(m.Key)) + 2
+		// the key and colon
+		c.SavedTokens += 2
+		if c.KeyLengths == nil {
+			c.KeyLengths = map[int]uint64{}
+		}
+		c.KeyLengths[len(m.Key)]++
+		if m.Package {
+			c.CrossPackage++
+		}
+	}
 }
 
 func (c *Count) Add(o *Count) {
 	c.Literals += o.Literals
+	c.Lines += o.Lines
+	c.Duplicates += o.Duplicates
+	c.Implicit += o.Implicit
+	c.MultiLine += o.MultiLine
+	c.Options += o.Options
+	c.OptionCalls += o.OptionCalls
+	c.OptionArgs += o.OptionArgs
+	c.Generic += o.Generic
+	c.Alias += o.Alias
+	c.DotImport += o.DotImport
 	c.KV += o.KV
 	c.NotIdent += o.NotIdent
+	c.BadKey += o.BadKey
+	c.Untyped += o.Untyped
+	c.Cgo += o.Cgo
+	c.Collapsed += o.Collapsed
+	c.NoSource += o.NoSource
+	c.SavedChars += o.SavedChars
+	c.SavedTokens += o.SavedTokens
+	c.Hazard += o.Hazard
+	c.AllExact += o.AllExact
+	c.AllButOne += o.AllButOne
+	c.CrossPackage += o.CrossPackage
+	c.ExactObjects += o.ExactObjects
+	for i, n := range o.ExactFraction {
+		c.ExactFraction[i] += n
+	}
+	c.addTypes(o)
+	c.addSplits(o)
+	c.addFields(o)
+	c.addTypeTallies(o)
+	for r, n := range o.Rules {
+		if c.Rules == nil {
+			c.Rules = map[string]uint64{}
+		}
+		c.Rules[r] += n
+	}
+	for r, n := range o.RulePartials {
+		if c.RulePartials == nil {
+			c.RulePartials = map[string]uint64{}
+		}
+		c.RulePartials[r] += n
+	}
+	for f, n := range o.Folds {
+		if c.Folds == nil {
+			c.Folds = map[string]uint64{}
+		}
+		c.Folds[f] += n
+	}
+	for n, k := range o.KeyLengths {
+		if c.KeyLengths == nil {
+			c.KeyLengths = map[int]uint64{}
+		}
+		c.KeyLengths[n] += k
+	}
+	for l, n := range o.PairLengths {
+		if c.PairLengths == nil {
+			c.PairLengths = map[string]uint64{}
+		}
+		c.PairLengths[l] += n
+	}
+	c.ValueShorter += o.ValueShorter
+	c.ValueLonger += o.ValueLonger
+	for e, n := range o.Constraints {
+		if c.Constraints == nil {
+			c.Constraints = map[string]uint64{}
+		}
+		c.Constraints[e] += n
+	}
+	for w, r := range o.Reflow {
+		if c.Reflow == nil {
+			c.Reflow = map[string]*Reflow{}
+		}
+		if c.Reflow[w] == nil {
+			c.Reflow[w] = &Reflow{}
+		}
+		c.Reflow[w].Literals += r.Literals
+		c.Reflow[w].Lines += r.Lines
+	}
+	for n, k := range o.Runs {
+		if c.Runs == nil {
+			c.Runs = map[int]uint64{}
+		}
+		c.Runs[n] += k
+	}
+	for n, k := range o.Sizes {
+		if c.Sizes == nil {
+			c.Sizes = map[int]uint64{}
+		}
+		c.Sizes[n] += k
+	}
 	c.Ident.Add(o.Ident)
 	c.QualifiedIdent.Add(o.QualifiedIdent)
 	c.Star.Add(o.Star)
 	c.QualifiedStar.Add(o.QualifiedStar)
 	c.Amp.Add(o.Amp)
 	c.QualifiedAmp.Add(o.QualifiedAmp)
+	c.Assignments.Add(o.Assignments)
+	c.Anonymous += o.Anonymous
+	c.AnonymousPairs.Add(o.AnonymousPairs)
+	c.Nested.Add(o.Nested)
+	c.Recent.Add(o.Recent)
 }
 
 func (c *Count) String() string {
+	return c.Format(false, false)
+}
+
+// Format returns the text form of c, with the match columns
+// in ANSI colors if color is set and the secondary tallies if detail is.
+func (c *Count) Format(color, detail bool) string {
 	var b strings.Builder
 	fmt.Fprintf(&b, "%s: ", c.ID)
-	if c.Literals == 0 {
+	switch {
+	case c.Literals == 0 && c.NoSource > 0 && c.Lines == 0:
+		b.WriteString("no source analyzed, only export data\n")
+		return b.String()
+	case c.Literals == 0 && c.NoSource > 0:
+		fmt.Fprintf(&b, "no keyed struct literals (%d packages with no source analyzed)\n", c.NoSource)
+		return b.String()
+	case c.Literals == 0:
 		b.WriteString("no keyed struct literals\n")
 		return b.String()
 	}
-	fmt.Fprintf(&b, "\n\tkeyed struct literals: %d\n", c.Literals)
-	fmt.Fprintf(&b, "\ttotal KV pairs: %d\n\tnon-candidate KV pairs: %d\n", c.KV, c.NotIdent)
-	tally := func(nm string, qual bool, t *Tally) {
-		if t.Total == 0 {
-			return
+	b.WriteString("\n")
+
+	// the tabwriters write to t so it can all be indented after
+	var t strings.Builder
+	tw := tabwriter.NewWriter(&t, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(tw, "keyed struct literals:\t%d (%d with the type elided, %d multi-line)\n", c.Literals, c.Implicit, c.MultiLine)
+	if c.Duplicates > 0 {
+		fmt.Fprintf(tw, "duplicate literals:\t%d\n", c.Duplicates)
+	}
+	if c.Lines > 0 {
+		fmt.Fprintf(tw, "lines of code:\t%d (%.1f exact matches per 1000)\n", c.Lines, c.Metrics()["exact_per_kloc"])
+	}
+	fmt.Fprintf(tw, "all pairs exact:\t%d (%d all but one)\n", c.AllExact, c.AllButOne)
+	if !detail {
+		var fs []string
+		for i, n := range c.ExactFraction {
+			fs = append(fs, fmt.Sprintf("%s%%: %d", FractionBuckets[i], n))
 		}
-		fmt.Fprintf(&b, "\t%s:\n", nm)
-		fmt.Fprintf(&b, "\t\ttotal: %d\n", t.Total)
-		fmt.Fprintf(&b, "\t\tno match: %d\n", t.Total-t.Exact-t.EqualsFold)
-		fmt.Fprintf(&b, "\t\texact: %d\n", t.Exact)
-		fmt.Fprintf(&b, "\t\tpartial: ")
-		if qual {
-			fmt.Fprintf(&b, "N/A\n")
-		} else {
-			fmt.Fprintf(&b, "%d\n", t.EqualsFold)
+		fmt.Fprintf(tw, "literals by exact pairs:\t%s\n", strings.Join(fs, ", "))
+	}
+	fmt.Fprintf(tw, "total KV pairs:\t%d\n", c.KV)
+	fmt.Fprintf(tw, "non-candidate KV pairs:\t%d\n", c.NotIdent)
+	if c.BadKey > 0 {
+		fmt.Fprintf(tw, "keys that are not names:\t%d\n", c.BadKey)
+	}
+	if c.NoSource > 0 {
+		fmt.Fprintf(tw, "packages with no source analyzed:\t%d\n", c.NoSource)
+	}
+	if c.Collapsed > 0 {
+		fmt.Fprintf(tw, "files counted by another package:\t%d\n", c.Collapsed)
+	}
+	if c.Cgo > 0 {
+		fmt.Fprintf(tw, "packages using cgo:\t%d\n", c.Cgo)
+	}
+	if c.Untyped > 0 {
+		fmt.Fprintf(tw, "skipped for missing types:\t%d\n", c.Untyped)
+	}
+	if c.SavedChars > 0 {
+		fmt.Fprintf(tw, "shorthand would save:\t%d chars, %d tokens\n", c.SavedChars, c.SavedTokens)
+	}
+	if c.ExactObjects > 0 {
+		fmt.Fprintf(tw, "distinct exact match values:\t%d\n", c.ExactObjects)
+	}
+	if s, ok := c.KeyLengthStats(); ok {
+		fmt.Fprintf(tw, "exact match key length:\tmin %d, median %s, mean %.1f, max %d\n", s.Min, formatMetric(s.Median), s.Mean, s.Max)
+	}
+	if len(c.Rules) > 0 {
+		fmt.Fprintf(tw, "matches by rule:\t%s\n", c.formatRules())
+	}
+	if len(c.Folds) > 0 {
+		fmt.Fprintf(tw, "partial matches by -fold:\t%s\n", c.formatFolds())
+	}
+	if detail && len(c.Reflow) > 0 {
+		fmt.Fprintf(tw, "fit on one line if elided:\t%s\n", c.formatReflow())
+	}
+	if len(c.Constraints) > 0 {
+		fmt.Fprintf(tw, "under //go:build constraints:\t%s\n", c.formatConstraints())
+	}
+	if a := c.Assignments; detail && a.Total > 0 {
+		fmt.Fprintf(tw, "x.Field = ident assignments:\t%d (%d exact, %d partial)\n", a.Total, a.Exact, a.EqualsFold)
+	}
+	if r := c.Recent; r.Total > 0 {
+		fmt.Fprintf(tw, "value declared just before:\t%d (%d exact, %d partial)\n", r.Total, r.Exact, r.EqualsFold)
+	}
+	if n := c.Nested; n.Total > 0 {
+		fmt.Fprintf(tw, "nested literal values:\t%d (type named as key %d, partial %d)\n", n.Total, n.Exact, n.EqualsFold)
+	}
+	switch {
+	case detail && (c.Options > 0 || c.OptionCalls > 0):
+		fmt.Fprintf(tw, "options struct literals:\t%d (and %d functional options calls passing %d options)\n", c.Options, c.OptionCalls, c.OptionArgs)
+	case c.Options > 0:
+		fmt.Fprintf(tw, "options struct literals:\t%d\n", c.Options)
+	}
+	if a := c.AnonymousPairs; c.Anonymous > 0 {
+		fmt.Fprintf(tw, "anonymous struct literals:\t%d (%d candidate pairs, %d exact, %d partial)\n", c.Anonymous, a.Total, a.Exact, a.EqualsFold)
+	}
+	if c.Generic > 0 {
+		fmt.Fprintf(tw, "generic struct literals:\t%d\n", c.Generic)
+	}
+	if c.Alias > 0 {
+		fmt.Fprintf(tw, "literals of an alias:\t%d\n", c.Alias)
+	}
+	if c.DotImport > 0 {
+		fmt.Fprintf(tw, "dot imported, counted as qualified:\t%d\n", c.DotImport)
+	}
+	if c.CrossPackage > 0 {
+		fmt.Fprintf(tw, "exact from other packages:\t%d\n", c.CrossPackage)
+	}
+	if c.Hazard > 0 {
+		fmt.Fprintf(tw, "bare key means something else:\t%d\n", c.Hazard)
+	}
+	tw.Flush()
+	if detail {
+		var fs []string
+		for _, b := range FractionBuckets {
+			fs = append(fs, b+"%")
+		}
+		writeHistogram(&t, "literals by exact pairs", fs, c.ExactFraction[:])
+		c.writeSizes(&t)
+		c.writeRuns(&t)
+		c.writeKeyLengths(&t)
+	}
+
+	tallies := []struct {
+		name string
+		qual bool
+		t    *Tally
+	}{
+		{"ident", false, c.Ident},
+		{"qual.ident", true, c.QualifiedIdent},
+		{"*ident", false, c.Star},
+		{"*qual.ident", true, c.QualifiedStar},
+		{"&ident", false, c.Amp},
+		{"&qual.ident", true, c.QualifiedAmp},
+	}
+	some := false
+	for _, t := range tallies {
+		some = some || t.t.Total > 0
+	}
+	if some {
+		// every cell in a column gets the same escapes so they stay aligned
+		paint := func(code, s string) string {
+			if !color {
+				return s
+			}
+			return "\x1b[" + code + "m" + s + "\x1b[0m"
+		}
+		const (
+			none    = "31"
+			exact   = "32"
+			partial = "33"
+		)
+		// names are padded to the same width so right aligning leaves them left aligned
+		// and the other cells bring their own space between columns
+		name := func(s string) string {
+			return fmt.Sprintf("%-11s", s)
+		}
+		tw = tabwriter.NewWriter(&t, 0, 8, 0, ' ', tabwriter.AlignRight)
+		fmt.Fprintf(tw, "%s\t  total\t  %s\t  %s\t  %s\t\n", name(""), paint(none, "no match"), paint(exact, "exact"), paint(partial, "partial"))
+		for _, t := range tallies {
+			if t.t.Total == 0 {
+				continue
+			}
+			p := "N/A"
+			if !t.qual {
+				p = strconv.FormatUint(t.t.EqualsFold, 10)
+			}
+			fmt.Fprintf(tw, "%s\t  %d\t  %s\t  %s\t  %s\t\n", name(t.name), t.t.Total,
+				paint(none, strconv.FormatUint(t.t.Total-t.t.Exact-t.t.EqualsFold, 10)),
+				paint(exact, strconv.FormatUint(t.t.Exact, 10)),
+				paint(partial, p))
+		}
+		tw.Flush()
+	}
+	if detail {
+		c.writePairLengths(&t)
+	}
+	c.writeTypes(&t)
+	c.writeSplits(&t)
+	c.writeTopFields(&t)
+	c.writeTopTypes(&t)
+
+	for _, line := range strings.SplitAfter(t.String(), "\n") {
+		if line != "" {
+			b.WriteString("\t" + line)
 		}
 	}
-	tally("ident", false, c.Ident)
-	tally("qual.ident", true, c.QualifiedIdent)
-	tally("*ident", false, c.Star)
-	tally("*qual.ident", true, c.QualifiedStar)
-	tally("&ident", false, c.Amp)
-	tally("&qual.ident", true, c.QualifiedAmp)
 	return b.String()
 }
 
+// KeyLengths summarizes the lengths of the keys of exact matches.
+type KeyLengths struct {
+	Min, Max     int
+	Median, Mean float64
+}
+
+// KeyLengthStats returns the summary of c.KeyLengths
+// or false if there are no exact matches.
+func (c *Count) KeyLengthStats() (KeyLengths, bool) {
+	var lens []int
+	var n, sum uint64
+	for l, k := range c.KeyLengths {
+		lens = append(lens, l)
+		n += k
+		sum += uint64(l) * k
+	}
+	if n == 0 {
+		return KeyLengths{}, false
+	}
+	sort.Ints(lens)
+	s := KeyLengths{
+		Min:  lens[0],
+		Max:  lens[len(lens)-1],
+		Mean: float64(sum) / float64(n),
+	}
+	// the lengths at the middle one or two positions
+	at := func(i uint64) int {
+		for _, l := range lens {
+			if i < c.KeyLengths[l] {
+				return l
+			}
+			i -= c.KeyLengths[l]
+		}
+		return lens[len(lens)-1]
+	}
+	s.Median = float64(at((n-1)/2)+at(n/2)) / 2
+	return s, true
+}
+
 type Tally struct {
-	Total, Exact, EqualsFold uint64
+	Total      uint64 `json:"total"`
+	Exact      uint64 `json:"exact"`
+	EqualsFold uint64 `json:"partial"`
 }
 
 func (t *Tally) Count(Exact, EqualsFold bool) {
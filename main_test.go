@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeModule writes a module of the files, by name, to a temporary directory
+// and returns the directory.
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files["go.mod"] = "module m\n\ngo 1.22\n"
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCountImportsStd(t *testing.T) {
+	dir := writeModule(t, map[string]string{"m.go": `package m
+
+import "strings"
+
+type T struct{ Name string }
+
+func f(Name string) T { return T{Name: strings.TrimSpace(Name)} }
+
+func g(Name string) T { return T{Name: Name} }
+`})
+	ps, skipped, err := GetPackages(context.Background(), dir, []string{"./..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 1 || len(skipped) != 0 {
+		t.Fatalf("got %d packages and %d skipped, want 1 and 0", len(ps), len(skipped))
+	}
+	c := CountPackage(context.Background(), ps[0])
+	if c.Literals != 2 || c.KV != 2 || c.Ident.Exact != 1 {
+		t.Errorf("got %d literals, %d pairs, and %d exact, want 2, 2, and 1", c.Literals, c.KV, c.Ident.Exact)
+	}
+}
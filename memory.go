@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"runtime"
+	"runtime/debug"
+	rtmetrics "runtime/metrics"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var maxMemory ByteSize
+
+func init() {
+	countFlags.Var(&maxMemory, "max-memory", "keep the heap under `size`, such as 4GiB, by loading the packages a batch at a time and, when the heap nears size, in ever smaller batches with each package's syntax and types released once counted (0 for no limit)")
+}
+
+// ByteSize is a flag.Value of a number of bytes
+// with an optional unit of B, KiB, MiB, GiB, or TiB, as in GOMEMLIMIT.
+type ByteSize uint64
+
+func (b *ByteSize) String() string {
+	return strconv.FormatUint(uint64(*b), 10)
+}
+
+func (b *ByteSize) Set(s string) error {
+	n, mult := s, uint64(1)
+	for i, unit := range []string{"KiB", "MiB", "GiB", "TiB"} {
+		if t, ok := strings.CutSuffix(s, unit); ok {
+			n, mult = t, 1<<(10*(i+1))
+			break
+		}
+	}
+	n = strings.TrimSuffix(n, "B")
+	v, err := strconv.ParseUint(n, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a size such as 512MiB or 4GiB", s)
+	}
+	if v > math.MaxUint64/mult {
+		return fmt.Errorf("%q is more bytes than fit in 64 bits", s)
+	}
+	*b = ByteSize(v * mult)
+	return nil
+}
+
+// nearLimit is the fraction of -max-memory above which the batches shrink.
+const nearLimit = 0.75
+
+// MemoryGuard loads and counts packages a batch at a time
+// to keep the heap under a limit.
+type MemoryGuard struct {
+	limit uint64
+	// batch is the number of packages to load at once.
+	batch int
+	// slow is set once the heap has neared the limit
+	// and each package is released as soon as it is counted.
+	slow bool
+}
+
+// NewMemoryGuard returns the guard for -max-memory or nil without it.
+// It also sets the limit as the soft memory limit of the runtime
+// so the collector works harder as the heap nears it.
+func NewMemoryGuard() *MemoryGuard {
+	if maxMemory == 0 {
+		return nil
+	}
+	debug.SetMemoryLimit(int64(maxMemory))
+	return &MemoryGuard{limit: uint64(maxMemory), batch: *batch}
+}
+
+// List returns the import paths of the packages matching pattern
+// without loading their syntax or types, sampled by -sample.
+func (g *MemoryGuard) List(ctx context.Context, pattern []string) ([]string, error) {
+	cfg, err := NewConfig(ctx, "", packages.NeedName)
+	if err != nil {
+		return nil, err
+	}
+	ps, err := LoadBatched(cfg, pattern)
+	if err != nil {
+		loadErrors.Add(1)
+		return nil, err
+	}
+	var roots []*packages.Package
+	// only the packages themselves: loading each loads its test variants
+	for _, p := range ps {
+		if p.ID != p.PkgPath {
+			continue
+		}
+		if p.PkgPath == "command-line-arguments" {
+			return nil, errors.New("-max-memory needs package patterns, not files")
+		}
+		roots = append(roots, p)
+	}
+	var paths []string
+	for _, p := range sample.SamplePackages(roots) {
+		paths = append(paths, p.PkgPath)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no packages to load")
+	}
+	return paths, nil
+}
+
+// Each loads the packages of paths a batch at a time and calls count with each.
+// It returns the packages, released, and those skipped for errors.
+func (g *MemoryGuard) Each(ctx context.Context, paths []string, count func(*packages.Package)) (ps []*packages.Package, skipped []PackageError, err error) {
+	for len(paths) > 0 && ctx.Err() == nil {
+		n := g.batch
+		if n <= 0 || n > len(paths) {
+			n = len(paths)
+		}
+		b, s, err := GetPackages(ctx, "", paths[:n])
+		if err != nil {
+			return nil, nil, err
+		}
+		paths = paths[n:]
+		skipped = append(skipped, s...)
+		for _, p := range b {
+			if ctx.Err() != nil {
+				break
+			}
+			count(p)
+			if g.slow {
+				release(p)
+			}
+		}
+		// before the heap is near the limit, the batch is released all at once
+		for _, p := range b {
+			release(p)
+		}
+		ps = append(ps, b...)
+		g.check(n)
+	}
+	return ps, skipped, nil
+}
+
+// check halves the batch of n packages, down to one,
+// when the heap is near the limit after it.
+func (g *MemoryGuard) check(n int) {
+	// only count what the released packages still hold
+	runtime.GC()
+	heap := heapBytes()
+	if float64(heap) < nearLimit*float64(g.limit) {
+		return
+	}
+	if !g.slow {
+		log.Printf("heap of %d bytes is near -max-memory: loading fewer packages at a time", heap)
+		g.slow = true
+	}
+	g.batch = n / 2
+	if g.batch < 1 {
+		g.batch = 1
+	}
+	debug.FreeOSMemory()
+}
+
+// release drops the syntax, types, and dependencies of p
+// so they can be collected while the rest of the run goes on,
+// keeping only what identifies it.
+func release(p *packages.Package) {
+	p.Syntax = nil
+	p.TypesInfo = nil
+	p.Types = nil
+	p.Imports = nil
+	p.Fset = nil
+}
+
+// heapBytes returns the bytes of heap objects, live or not yet collected.
+func heapBytes() uint64 {
+	s := []rtmetrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	rtmetrics.Read(s)
+	if s[0].Value.Kind() != rtmetrics.KindUint64 {
+		return 0
+	}
+	return s[0].Value.Uint64()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// SchemaVersion is the version of the structure of the JSON and CSV outputs.
+// It changes when a field is renamed, removed, or changes meaning,
+// not when one is added.
+const SchemaVersion = 1
+
+var metaFile = outputFlags.String("meta", "", "also write the metadata of the run, as embedded in every JSON and CSV output, to `file`")
+
+// Invocation is how the tool was run.
+type Invocation struct {
+	Command string `json:"command"`
+	// Flags is the value of every flag of the command,
+	// whether given, set by the config file, or the default.
+	Flags map[string]string `json:"flags"`
+	// Args are the arguments after the flags, such as package patterns.
+	Args []string `json:"args"`
+}
+
+// invocation and started are set by main once the flags are parsed.
+var (
+	invocation Invocation
+	started    time.Time
+)
+
+// SetInvocation records the command run and its parsed flags.
+func SetInvocation(cmd string, fs *flag.FlagSet) {
+	started = time.Now()
+	invocation = Invocation{Command: cmd, Flags: map[string]string{}, Args: fs.Args()}
+	fs.VisitAll(func(f *flag.Flag) {
+		invocation.Flags[f.Name] = f.Value.String()
+	})
+}
+
+// Meta describes the run that wrote an output,
+// so archived results can be interpreted as the tool changes.
+type Meta struct {
+	SchemaVersion int `json:"schema_version"`
+	// ToolVersion is as for -cache and "" if unknown.
+	ToolVersion string `json:"tool_version"`
+	// Commit is the VCS revision the tool was built from, if known.
+	Commit string `json:"commit,omitempty"`
+	// Time is when the run started.
+	Time time.Time `json:"time"`
+	Invocation
+}
+
+// NewMeta returns the Meta of this run.
+func NewMeta() *Meta {
+	m := &Meta{
+		SchemaVersion: SchemaVersion,
+		ToolVersion:   ToolVersion(),
+		Time:          started.UTC().Truncate(time.Second),
+		Invocation:    invocation,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.revision" {
+				m.Commit = s.Value
+			}
+		}
+	}
+	return m
+}
+
+// metaRecord is the first record of the newline-delimited JSON outputs,
+// before the rows, which it is told from by having only a meta key.
+type metaRecord struct {
+	Meta *Meta `json:"meta"`
+}
+
+// writeMetaComment writes the Meta of this run as the first line of a CSV output,
+// a comment of # and the Meta as JSON,
+// which readers such as encoding/csv with Comment set to '#' skip.
+func writeMetaComment(w io.Writer) error {
+	bs, err := json.Marshal(NewMeta())
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "# %s\n", bs)
+	return err
+}
+
+// WriteMeta writes the Meta of this run to -meta, if given.
+func WriteMeta() error {
+	if *metaFile == "" {
+		return nil
+	}
+	bs, err := json.MarshalIndent(NewMeta(), "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(*metaFile, append(bs, '\n'), 0o644)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTotalMeta(t *testing.T) {
+	defer func(m JSONMode) { jsonMode = m }(jsonMode)
+	for _, flat := range []bool{false, true} {
+		jsonMode = JSONMode{on: true, flat: flat}
+		r := &Report{Meta: NewMeta(), Total: New("<total>")}
+		var buf bytes.Buffer
+		if err := r.writeTotal(json.NewEncoder(&buf)); err != nil {
+			t.Fatal(err)
+		}
+		var got struct {
+			ID   string `json:"id"`
+			Meta *Meta  `json:"meta"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Meta == nil || got.Meta.SchemaVersion != SchemaVersion || got.ID != "<total>" {
+			t.Errorf("flat %v: got %s, want the total with its meta", flat, buf.Bytes())
+		}
+	}
+}
+
+func TestSitesMeta(t *testing.T) {
+	defer func(v string) { sitesFormat.Value = v }(sitesFormat.Value)
+
+	sitesFormat.Value = "jsonl"
+	var buf bytes.Buffer
+	if _, err := newSiteWriter(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var rec metaRecord
+	if err := json.NewDecoder(&buf).Decode(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Meta == nil || rec.Meta.SchemaVersion != SchemaVersion {
+		t.Errorf("got %+v, want a first record of the meta", rec)
+	}
+
+	sitesFormat.Value = "csv"
+	buf.Reset()
+	sw, err := newSiteWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	line, _, _ := strings.Cut(buf.String(), "\n")
+	var m Meta
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "# ")), &m); err != nil || m.SchemaVersion != SchemaVersion {
+		t.Errorf("got first line %q, want a comment of the meta (%v)", line, err)
+	}
+	cr := csv.NewReader(&buf)
+	cr.Comment = '#'
+	records, err := cr.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || len(records[0]) != len(siteColumns) || records[0][0] != "package" {
+		t.Errorf("got records %q, want only the header", records)
+	}
+}
+
+func TestExportMeta(t *testing.T) {
+	dir := writeModule(t, map[string]string{"m.go": `package m
+
+type T struct{ Name string }
+
+func f(Name string) T { return T{Name: Name} }
+`})
+	defer func(d string) { *chdir = d }(*chdir)
+	*chdir = dir
+	var buf bytes.Buffer
+	if err := Export(context.Background(), &buf, []string{"./..."}); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(&buf)
+	var rec metaRecord
+	if err := dec.Decode(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Meta == nil || rec.Meta.SchemaVersion != SchemaVersion {
+		t.Errorf("got %+v, want a first record of the meta", rec)
+	}
+	var row map[string]any
+	if err := dec.Decode(&row); err != nil {
+		t.Fatal(err)
+	}
+	if row["package"] != "m" {
+		t.Errorf("got row %v, want the row of package m", row)
+	}
+}
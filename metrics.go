@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+)
+
+var (
+	failIf     Conditions
+	maxPartial = gateFlags.Int("max-partial", -1, "fail if the total number of partial matches is greater than `n` (-1 to disable)")
+	metrics    = gateFlags.Bool("metrics", false, "list the metrics that conditions can test and exit")
+)
+
+func init() {
+	gateFlags.Var(&failIf, "fail-if", "fail if the `condition` on the total holds, such as 'exact_ratio < 0.3' (may be repeated or comma-separated; see -metrics)")
+}
+
+// Metrics returns the named numbers that conditions can test.
+//
+// Each tally contributes name.total, name.exact, name.partial, and name.no_match
+// and the sums over all tallies are given without a prefix.
+// exact_ratio and partial_ratio are fractions of all KV pairs
+// and exact_per_kloc and partial_per_kloc are per 1000 lines of code.
+// exact_fraction.0 through exact_fraction.100 count literals
+// by their percentage of exact matches, such as exact_fraction.26_50.
+// key_length.min, median, mean, and max are over the keys of exact matches
+// and 0 without any.
+// size.max is the most KV pairs in a literal
+// and run.max the longest run of consecutive exact matches.
+// value_shorter and value_longer are the candidate pairs whose value is
+// shorter or longer than the key.
+// reflow_80.literals and reflow_80.lines are the multi-line literals that would
+// fit on a line of 80 columns only without the keys of exact matches,
+// and the lines that would save, and the same for the other widths.
+// constrained is the literals in files with a //go:build constraint.
+// rule.exact and the others are the matches under each -rule,
+// and rule_partial.exact and the others the partial matches
+// and fold.unicode and the others the partial matches under each -fold.
+// assignments.total, exact, partial, and no_match are for x.Field = ident
+// and are not in the sums.
+// anonymous.total and the others are the pairs of literals of anonymous struct types,
+// which are in the sums.
+// recent.total and the others are the candidate pairs whose value was declared
+// in the -recent statements before and are in the sums.
+// nested.total and the others are the pairs whose value is a composite literal,
+// matched against the name of its type, and are not in the sums.
+func (c *Count) Metrics() map[string]float64 {
+	m := map[string]float64{
+		"literals":   float64(c.Literals),
+		"lines":      float64(c.Lines),
+		"duplicates": float64(c.Duplicates),
+		"implicit":   float64(c.Implicit),
+		"multi_line": float64(c.MultiLine),
+		"options":    float64(c.Options),
+
+		"option_calls": float64(c.OptionCalls),
+		"option_args":  float64(c.OptionArgs),
+		"generic":      float64(c.Generic),
+		"alias":        float64(c.Alias),
+		"dot_import":   float64(c.DotImport),
+		"kv":           float64(c.KV),
+		"not_ident":    float64(c.NotIdent),
+		"bad_key":      float64(c.BadKey),
+		"untyped":      float64(c.Untyped),
+		"cgo":          float64(c.Cgo),
+		"collapsed":    float64(c.Collapsed),
+		"no_source":    float64(c.NoSource),
+		"anonymous":    float64(c.Anonymous),
+
+		"saved_chars":  float64(c.SavedChars),
+		"saved_tokens": float64(c.SavedTokens),
+		"hazard":       float64(c.Hazard),
+		"all_exact":    float64(c.AllExact),
+		"all_but_one":  float64(c.AllButOne),
+
+		"cross_package": float64(c.CrossPackage),
+		"exact_objects": float64(c.ExactObjects),
+		"value_shorter": float64(c.ValueShorter),
+		"value_longer":  float64(c.ValueLonger),
+	}
+	sum := &Tally{}
+	for _, t := range []struct {
+		name string
+		*Tally
+	}{
+		{"ident", c.Ident},
+		{"qualified_ident", c.QualifiedIdent},
+		{"star", c.Star},
+		{"qualified_star", c.QualifiedStar},
+		{"amp", c.Amp},
+		{"qualified_amp", c.QualifiedAmp},
+	} {
+		m[t.name+".total"] = float64(t.Total)
+		m[t.name+".exact"] = float64(t.Exact)
+		m[t.name+".partial"] = float64(t.EqualsFold)
+		m[t.name+".no_match"] = float64(t.Total - t.Exact - t.EqualsFold)
+		sum.Add(t.Tally)
+	}
+	for i, n := range c.ExactFraction {
+		m["exact_fraction."+strings.ReplaceAll(FractionBuckets[i], "-", "_")] = float64(n)
+	}
+	for _, r := range structlit.RuleNames {
+		m["rule."+r] = float64(c.Rules[r])
+		m["rule_partial."+r] = float64(c.RulePartials[r])
+	}
+	for _, f := range FoldNames {
+		m["fold."+f] = float64(c.Folds[f])
+	}
+	kl, _ := c.KeyLengthStats()
+	m["key_length.min"] = float64(kl.Min)
+	m["key_length.median"] = kl.Median
+	m["key_length.mean"] = kl.Mean
+	m["key_length.max"] = float64(kl.Max)
+	m["size.max"] = 0
+	m["run.max"] = 0
+	for n := range c.Runs {
+		m["run.max"] = math.Max(m["run.max"], float64(n))
+	}
+	for _, w := range ReflowWidths {
+		r := c.Reflow[strconv.Itoa(w)]
+		if r == nil {
+			r = &Reflow{}
+		}
+		m["reflow_"+strconv.Itoa(w)+".literals"] = float64(r.Literals)
+		m["reflow_"+strconv.Itoa(w)+".lines"] = float64(r.Lines)
+	}
+	m["constrained"] = 0
+	for _, n := range c.Constraints {
+		m["constrained"] += float64(n)
+	}
+	for n := range c.Sizes {
+		m["size.max"] = math.Max(m["size.max"], float64(n))
+	}
+	a := c.Assignments
+	m["assignments.total"] = float64(a.Total)
+	m["assignments.exact"] = float64(a.Exact)
+	m["assignments.partial"] = float64(a.EqualsFold)
+	m["assignments.no_match"] = float64(a.Total - a.Exact - a.EqualsFold)
+	r := c.Recent
+	m["recent.total"] = float64(r.Total)
+	m["recent.exact"] = float64(r.Exact)
+	m["recent.partial"] = float64(r.EqualsFold)
+	m["recent.no_match"] = float64(r.Total - r.Exact - r.EqualsFold)
+	n := c.Nested
+	m["nested.total"] = float64(n.Total)
+	m["nested.exact"] = float64(n.Exact)
+	m["nested.partial"] = float64(n.EqualsFold)
+	m["nested.no_match"] = float64(n.Total - n.Exact - n.EqualsFold)
+	an := c.AnonymousPairs
+	m["anonymous.total"] = float64(an.Total)
+	m["anonymous.exact"] = float64(an.Exact)
+	m["anonymous.partial"] = float64(an.EqualsFold)
+	m["anonymous.no_match"] = float64(an.Total - an.Exact - an.EqualsFold)
+	m["total"] = float64(sum.Total)
+	m["exact"] = float64(sum.Exact)
+	m["partial"] = float64(sum.EqualsFold)
+	m["no_match"] = float64(sum.Total - sum.Exact - sum.EqualsFold)
+	m["exact_ratio"] = ratio(sum.Exact, c.KV)
+	m["partial_ratio"] = ratio(sum.EqualsFold, c.KV)
+	m["exact_per_kloc"] = 1000 * ratio(sum.Exact, c.Lines)
+	m["partial_per_kloc"] = 1000 * ratio(sum.EqualsFold, c.Lines)
+	return m
+}
+
+func ratio(n, d uint64) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+// MetricNames returns the sorted names of all metrics.
+func MetricNames() []string {
+	var names []string
+	for k := range New("").Metrics() {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Condition compares a metric against a constant.
+type Condition struct {
+	Metric string
+	Op     string
+	Value  float64
+}
+
+// ParseCondition parses "metric op value" where op is one of < <= > >= == !=.
+func ParseCondition(s string) (Condition, error) {
+	f := strings.Fields(s)
+	if len(f) != 3 {
+		return Condition{}, fmt.Errorf("condition %q is not: metric op value", s)
+	}
+	c := Condition{Metric: f[0], Op: f[1]}
+	if _, ok := New("").Metrics()[c.Metric]; !ok {
+		return Condition{}, fmt.Errorf("condition %q: unknown metric %q", s, c.Metric)
+	}
+	switch c.Op {
+	case "<", "<=", ">", ">=", "==", "!=":
+	default:
+		return Condition{}, fmt.Errorf("condition %q: unknown operator %q", s, c.Op)
+	}
+	v, err := strconv.ParseFloat(f[2], 64)
+	if err != nil {
+		return Condition{}, fmt.Errorf("condition %q: %w", s, err)
+	}
+	c.Value = v
+	return c, nil
+}
+
+// Holds reports whether the condition is true for m.
+func (c Condition) Holds(m map[string]float64) bool {
+	v := m[c.Metric]
+	switch c.Op {
+	case "<":
+		return v < c.Value
+	case "<=":
+		return v <= c.Value
+	case ">":
+		return v > c.Value
+	case ">=":
+		return v >= c.Value
+	case "==":
+		return v == c.Value
+	case "!=":
+		return v != c.Value
+	}
+	return false
+}
+
+func (c Condition) String() string {
+	return fmt.Sprintf("%s %s %s", c.Metric, c.Op, formatMetric(c.Value))
+}
+
+func formatMetric(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Conditions is a flag.Value collecting repeated conditions.
+type Conditions []Condition
+
+func (cs *Conditions) String() string {
+	var s []string
+	for _, c := range *cs {
+		s = append(s, c.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+// Set adds one or more comma-separated conditions.
+func (cs *Conditions) Set(s string) error {
+	for _, part := range strings.Split(s, ",") {
+		c, err := ParseCondition(part)
+		if err != nil {
+			return err
+		}
+		*cs = append(*cs, c)
+	}
+	return nil
+}
+
+// GateError is returned by Check when a condition holds.
+// The report is complete but the run should fail.
+type GateError struct {
+	Failed []string
+}
+
+func (e *GateError) Error() string {
+	return "failed: " + strings.Join(e.Failed, "; ")
+}
+
+// Check returns a *GateError listing every condition and -alerts alert that holds for total.
+func Check(total *Count, cs []Condition) error {
+	m := total.Metrics()
+	var failed []string
+	for _, c := range cs {
+		if c.Holds(m) {
+			failed = append(failed, fmt.Sprintf("%s (%s = %s)", c, c.Metric, formatMetric(m[c.Metric])))
+		}
+	}
+	more, err := alerts.Failed(total)
+	if err != nil {
+		return err
+	}
+	failed = append(failed, more...)
+	if len(failed) > 0 {
+		return &GateError{Failed: failed}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// NestedType returns the name of the named type of the composite literal
+// that is the value of kv, or of what it points to, as in Spec: Spec{...}
+// or Spec: &Spec{...}.
+// The name is "" for a literal of an unnamed type
+// and ok is false if the value is not a composite literal.
+func NestedType(info *types.Info, kv *ast.KeyValueExpr) (name string, ok bool) {
+	x := kv.Value
+	if u, isUnary := x.(*ast.UnaryExpr); isUnary && u.Op == token.AND {
+		x = u.X
+	}
+	c, ok := x.(*ast.CompositeLit)
+	if !ok {
+		return "", false
+	}
+	typ := info.TypeOf(c)
+	if ptr, isPtr := typ.(*types.Pointer); isPtr {
+		typ = ptr.Elem()
+	}
+	if named, isNamed := typ.(*types.Named); isNamed {
+		return named.Origin().Obj().Name(), true
+	}
+	return "", true
+}
+
+// countNested tallies in c.Nested the pair kv if its value is a composite literal,
+// where a match is between the key and the name of the type of the literal.
+func (c *Count) countNested(info *types.Info, kv *ast.KeyValueExpr) {
+	name, ok := NestedType(info, kv)
+	if !ok {
+		return
+	}
+	key := kv.Key.(*ast.Ident).Name
+	c.Nested.Count(name == key, name != "" && name != key && Fold(key, name))
+}
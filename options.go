@@ -0,0 +1,62 @@
+package main
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var optionsSuffixes = countFlags.String("options-suffixes", "Options,Config,Params", "comma-separated `suffixes` of the names of options structs, such as Options in ServerOptions")
+
+// IsOptionsStruct reports whether typ, or what it points to, is a named type
+// whose name ends with one of -options-suffixes.
+func IsOptionsStruct(typ types.Type) bool {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+	name := named.Obj().Name()
+	for _, s := range strings.Split(*optionsSuffixes, ",") {
+		if s = strings.TrimSpace(s); s != "" && strings.HasSuffix(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// CountOptionCalls counts in c the calls in f that pass options
+// to a variadic parameter of a type named Option or ending in Option, as in
+// grpc.Dial(target, grpc.WithBlock()), the functional options alternative to options structs.
+func CountOptionCalls(c *Count, p *packages.Package, f *ast.File) {
+	skipped := Skipped(f)
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || skipped(call.Pos()) {
+			return true
+		}
+		sig, ok := p.TypesInfo.TypeOf(call.Fun).(*types.Signature)
+		if !ok || !sig.Variadic() || len(call.Args) < sig.Params().Len() {
+			return true
+		}
+		// the variadic parameter of append is a string for append(b, s...)
+		last, ok := sig.Params().At(sig.Params().Len() - 1).Type().(*types.Slice)
+		if !ok {
+			return true
+		}
+		named, ok := last.Elem().(*types.Named)
+		if !ok || !strings.HasSuffix(named.Obj().Name(), "Option") {
+			return true
+		}
+		c.OptionCalls++
+		// opts... passes an unknown number
+		if !call.Ellipsis.IsValid() {
+			c.OptionArgs += uint64(len(call.Args) - sig.Params().Len() + 1)
+		}
+		return true
+	})
+}
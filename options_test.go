@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCountOptionCalls(t *testing.T) {
+	dir := writeModule(t, map[string]string{"m.go": `package m
+
+type Option func(*T)
+
+type T struct{ Name string }
+
+func New(opts ...Option) *T { return &T{} }
+
+func f(b []byte) []byte {
+	New(nil, nil)
+	return append(b, "abc"...)
+}
+`})
+	ps, _, err := GetPackages(context.Background(), dir, []string{"./..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := CountPackage(context.Background(), ps[0])
+	if c.OptionCalls != 1 || c.OptionArgs != 2 {
+		t.Errorf("got %d calls passing %d options, want 1 passing 2", c.OptionCalls, c.OptionArgs)
+	}
+}
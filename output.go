@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	outFile   = outputFlags.String("o", "", "write the report to `file` instead of stdout; %d is replaced by the first unused number and %t by a timestamp")
+	colorMode = NewEnum(outputFlags, "color", "auto", "color the match columns of the text report; auto colors only a terminal", "auto", "always", "never")
+	quiet     = outputFlags.Bool("q", false, "write only a tab-separated line per package and the total: id, literals, KV pairs, exact, partial, no match, and exact ratio")
+	detail    = outputFlags.Bool("detail", false, "also write the secondary tallies of the text report: the histograms, the table of pairs by key and value length, and the counts of field assignments, functional options calls, and literals that would fit on one line")
+
+	noTotal     = outputFlags.Bool("no-total", false, "never write the <total> of the text report")
+	totalAlways = outputFlags.Bool("total-always", false, "write the <total> of the text report even for a single package")
+	totalsOnly  = outputFlags.Bool("totals-only", false, "write only the <total> and, with -json, as a single JSON object of the count")
+)
+
+// showTotal reports whether the text report has the total:
+// by default only when there are many counts to total.
+func showTotal(many bool) bool {
+	switch {
+	case *noTotal:
+		return false
+	case *totalAlways:
+		return true
+	}
+	return many
+}
+
+// writeCount writes c as text or, with -q, as its DataLine.
+func writeCount(w io.Writer, c *Count) error {
+	if *quiet {
+		_, err := fmt.Fprintln(w, c.DataLine())
+		return err
+	}
+	s := c.Format(UseColor(), *detail)
+	if baseline != nil {
+		s += FormatDelta(baseline.Delta(c))
+	}
+	_, err := fmt.Fprintln(w, s)
+	return err
+}
+
+// DataLine is c as a tab-separated line with no prose:
+// its ID, literals, KV pairs, exact, partial, no match, and exact ratio.
+func (c *Count) DataLine() string {
+	m := c.Metrics()
+	return fmt.Sprintf("%s\t%d\t%d\t%d\t%d\t%d\t%s", c.ID, c.Literals, c.KV, int(m["exact"]), int(m["partial"]), int(m["no_match"]), formatMetric(m["exact_ratio"]))
+}
+
+// UseColor reports whether the text report should be colored.
+// With -color=auto that is when it is written to a terminal
+// and NO_COLOR is not set.
+func UseColor() bool {
+	switch colorMode.Value {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if *outFile != "" || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// ExpandOutput replaces %t in name with the current UTC time,
+// %d with the smallest non-negative integer that gives the name of a file that does not exist yet,
+// and %% with %.
+func ExpandOutput(name string, now time.Time) (string, error) {
+	expand := func(n int) (string, error) {
+		var b strings.Builder
+		for i := 0; i < len(name); i++ {
+			if name[i] != '%' {
+				b.WriteByte(name[i])
+				continue
+			}
+			i++
+			if i == len(name) {
+				return "", fmt.Errorf("%s: trailing %%", name)
+			}
+			switch name[i] {
+			case '%':
+				b.WriteByte('%')
+			case 't':
+				b.WriteString(now.UTC().Format("20060102T150405Z"))
+			case 'd':
+				b.WriteString(strconv.Itoa(n))
+			default:
+				return "", fmt.Errorf("%s: unknown substitution %%%c", name, name[i])
+			}
+		}
+		return b.String(), nil
+	}
+	if !strings.Contains(strings.ReplaceAll(name, "%%", ""), "%d") {
+		return expand(0)
+	}
+	for n := 0; ; n++ {
+		s, err := expand(n)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Lstat(s); os.IsNotExist(err) {
+			return s, nil
+		}
+	}
+}
+
+// AtomicFile is written to a temporary file in the same directory as its name
+// and only renamed into place by Commit,
+// so readers never see a partial report.
+type AtomicFile struct {
+	*os.File
+	name string
+}
+
+// CreateAtomic returns an AtomicFile that will become name on Commit.
+func CreateAtomic(name string) (*AtomicFile, error) {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return nil, err
+	}
+	return &AtomicFile{File: f, name: name}, nil
+}
+
+// Commit closes the file and renames it into place.
+func (f *AtomicFile) Commit() error {
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.File.Name())
+		return err
+	}
+	// CreateTemp is 0600 but this is an ordinary output file
+	if err := os.Chmod(f.File.Name(), 0o644); err != nil {
+		os.Remove(f.File.Name())
+		return err
+	}
+	return os.Rename(f.File.Name(), f.name)
+}
+
+// Abort closes and removes the temporary file.
+func (f *AtomicFile) Abort() {
+	f.File.Close()
+	os.Remove(f.File.Name())
+}
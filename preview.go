@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var writeFiles = previewFlags.Bool("w", false, "with -fix-names, write the renames to the files and list them instead of printing a diff")
+
+// Preview runs the preview command: it prints a unified diff
+// that removes the key from every exact match,
+// showing the code as it would be written with the shorthand.
+// With -fix-names, it instead renames local variables with NameFixes.
+// No files are changed unless -w is given with -fix-names.
+//
+// Pairs whose value is not on the same line as the key are left alone.
+func Preview(ctx context.Context, w io.Writer, args []string) error {
+	if *jsonOut {
+		return errors.New("preview: -json is not supported")
+	}
+	args, err := Patterns(ctx, args)
+	if err != nil {
+		return err
+	}
+	ps, skipped, err := GetPackages(ctx, "", args)
+	if err != nil {
+		return err
+	}
+	LogSkipped(skipped)
+
+	edits := map[string][]Edit{}
+	for _, p := range ps {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// the offsets are in the files cgo translated, not the files as written
+		if UsesCgo(p) {
+			log.Printf("skipping %s: it uses cgo", p.ID)
+			continue
+		}
+		if *fixNames {
+			for f, es := range NameFixes(p) {
+				edits[f] = append(edits[f], es...)
+			}
+			continue
+		}
+		CountPackageFunc(ctx, p, func(s *Site) {
+			if s.Match == nil || !s.Match.Identical || s.Pos.Line != s.ValuePos.Line {
+				return
+			}
+			edits[s.Pos.Filename] = append(edits[s.Pos.Filename], Edit{s.Pos.Offset, s.ValuePos.Offset, ""})
+		})
+	}
+
+	var files []string
+	for f := range edits {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		src, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		name := RelPath(f)
+		out := ApplyEdits(string(src), edits[f])
+		if !*writeFiles {
+			fmt.Fprint(w, UnifiedDiff(filepath.ToSlash(name), string(src), out))
+			continue
+		}
+		fi, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(f, []byte(out), fi.Mode().Perm()); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, name)
+	}
+	return nil
+}
+
+// Edit replaces the bytes from Start up to End with New.
+type Edit struct {
+	Start, End int
+	New        string
+}
+
+// ApplyEdits returns src with the edits made.
+// Duplicate edits are made once and the others must not overlap.
+func ApplyEdits(src string, edits []Edit) string {
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].Start < edits[j].Start
+	})
+	var b strings.Builder
+	last := 0
+	for i, e := range edits {
+		if i > 0 && e == edits[i-1] {
+			continue
+		}
+		b.WriteString(src[last:e.Start])
+		b.WriteString(e.New)
+		last = e.End
+	}
+	b.WriteString(src[last:])
+	return b.String()
+}
+
+// UnifiedDiff returns a unified diff, with three lines of context,
+// between old and new, which must have the same number of lines,
+// as only lines that change in place are handled.
+func UnifiedDiff(name, old, new string) string {
+	a, b := strings.SplitAfter(old, "\n"), strings.SplitAfter(new, "\n")
+	if len(a) != len(b) {
+		panic("UnifiedDiff: line counts differ")
+	}
+	var changed []int
+	for i := range a {
+		if a[i] != b[i] {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return ""
+	}
+
+	const context = 3
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", name, name)
+	for i := 0; i < len(changed); {
+		// extend the hunk while the next change is within its context
+		j := i
+		for j+1 < len(changed) && changed[j+1]-changed[j] <= 2*context {
+			j++
+		}
+		start := changed[i] - context
+		if start < 0 {
+			start = 0
+		}
+		end := changed[j] + context + 1
+		if end > len(a) {
+			end = len(a)
+		}
+		// a trailing newline leaves an empty last "line"
+		if end == len(a) && a[end-1] == "" {
+			end--
+		}
+		n := end - start
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", start+1, n, start+1, n)
+		for k := start; k < end; k++ {
+			if a[k] == b[k] {
+				out.WriteString(" " + line(a[k]))
+				continue
+			}
+			out.WriteString("-" + line(a[k]))
+			out.WriteString("+" + line(b[k]))
+		}
+		i = j + 1
+	}
+	return out.String()
+}
+
+// line ensures s ends in a newline.
+func line(s string) string {
+	if strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n\\ No newline at end of file\n"
+}
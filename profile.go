@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+var (
+	cpuProfile = commonFlags.String("cpuprofile", "", "write a CPU profile to `file`")
+	memProfile = commonFlags.String("memprofile", "", "write a heap profile to `file` on exit")
+	traceFile  = commonFlags.String("trace", "", "write an execution trace to `file`")
+	pprofAddr  = commonFlags.String("pprof-addr", "", "serve net/http/pprof on `addr` while running")
+)
+
+// StartProfiling starts any profiling requested by flags.
+// The returned func stops it and writes the profiles out.
+func StartProfiling() (stop func(), err error) {
+	var stops []func()
+	stop = func() {
+		for i := len(stops) - 1; i >= 0; i-- {
+			stops[i]()
+		}
+	}
+
+	if *pprofAddr != "" {
+		go func() {
+			log.Println(http.ListenAndServe(*pprofAddr, nil))
+		}()
+	}
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			return nil, err
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+		stops = append(stops, func() {
+			pprof.StopCPUProfile()
+			if err := f.Close(); err != nil {
+				log.Println(err)
+			}
+		})
+	}
+
+	if *traceFile != "" {
+		f, err := os.Create(*traceFile)
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			stop()
+			return nil, err
+		}
+		stops = append(stops, func() {
+			trace.Stop()
+			if err := f.Close(); err != nil {
+				log.Println(err)
+			}
+		})
+	}
+
+	if *memProfile != "" {
+		stops = append(stops, func() {
+			f, err := os.Create(*memProfile)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			defer f.Close()
+			// get up-to-date statistics
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				log.Println(err)
+			}
+		})
+	}
+
+	return stop, nil
+}
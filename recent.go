@@ -0,0 +1,72 @@
+package main
+
+import (
+	"go/ast"
+	"go/types"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+)
+
+var recentStmts = countFlags.Int("recent", 3, "tally the candidate pairs whose value is a variable declared in the `n` statements before the one with the literal, in the same block (0 to disable)")
+
+// countRecent tallies in c.Recent the pairs of kvs, with the matches ms,
+// whose value is a variable declared in one of the -recent statements
+// before the statement containing the literal, whose enclosing nodes are path,
+// as in
+//
+//	name := f()
+//	x := T{Name: name}
+func (c *Count) countRecent(info *types.Info, path []ast.Node, kvs []*ast.KeyValueExpr, ms []*structlit.Match) {
+	if *recentStmts <= 0 {
+		return
+	}
+	before := precedingStmts(path, *recentStmts)
+	if len(before) == 0 {
+		return
+	}
+	for i, kv := range kvs {
+		if ms[i] == nil || ms[i].Selector {
+			continue
+		}
+		v, ok := info.Uses[structlit.ValueIdent(kv.Value)].(*types.Var)
+		if !ok {
+			continue
+		}
+		for _, s := range before {
+			if s.Pos() <= v.Pos() && v.Pos() < s.End() {
+				c.Recent.Count(ms[i].Identical, ms[i].Partial)
+				break
+			}
+		}
+	}
+}
+
+// precedingStmts returns up to n statements before the one containing
+// the node enclosed by path, in the innermost block, or case or select clause, of path.
+func precedingStmts(path []ast.Node, n int) []ast.Stmt {
+	for i := len(path) - 2; i >= 0; i-- {
+		var list []ast.Stmt
+		switch b := path[i].(type) {
+		case *ast.BlockStmt:
+			list = b.List
+		case *ast.CaseClause:
+			list = b.Body
+		case *ast.CommClause:
+			list = b.Body
+		default:
+			continue
+		}
+		for j, s := range list {
+			if s != path[i+1] {
+				continue
+			}
+			if j < n {
+				return list[:j]
+			}
+			return list[j-n : j]
+		}
+		// in the expressions of a case clause, not its body
+		return nil
+	}
+	return nil
+}
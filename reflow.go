@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+)
+
+// ReflowWidths are the line widths that countReflow tries to fit literals in.
+var ReflowWidths = []int{80, 100}
+
+// Reflow is the multi-line literals that would fit on one line
+// of some width only if their exact matches were written without keys,
+// and the lines that would save.
+type Reflow struct {
+	Literals uint64 `json:"literals"`
+	Lines    uint64 `json:"lines"`
+}
+
+// countReflow counts in c.Reflow the multi-line literal lit, with the matches ms,
+// if written on one line it would be too wide for a width of ReflowWidths
+// with its keys but not without the keys of its exact matches.
+//
+// The one line is everything before the literal on its first line,
+// then the literal as gofmt would write it, then a comma.
+// Columns are bytes, so a tab is one.
+func (c *Count) countReflow(fset *token.FileSet, lit *ast.CompositeLit, ms []*structlit.Match) {
+	if !IsMultiLine(fset, lit) {
+		return
+	}
+	start := fset.Position(lit.Lbrace)
+	full, elided := start.Column-1+2+1, start.Column-1+2+1
+	// ms is only of the keyed elements, which a literal with type errors may mix with others
+	kvs := 0
+	for i, e := range lit.Elts {
+		text, ok := oneLine(fset, e)
+		if !ok {
+			return
+		}
+		if i > 0 {
+			full += 2
+			elided += 2
+		}
+		full += len(text)
+		kv, isKV := e.(*ast.KeyValueExpr)
+		if isKV && ms[kvs] != nil && ms[kvs].Identical {
+			v, _ := oneLine(fset, kv.Value)
+			elided += len(v)
+		} else {
+			elided += len(text)
+		}
+		if isKV {
+			kvs++
+		}
+	}
+	lines := uint64(fset.Position(lit.Rbrace).Line - start.Line)
+	for _, w := range ReflowWidths {
+		if full <= w || elided > w {
+			continue
+		}
+		k := strconv.Itoa(w)
+		if c.Reflow == nil {
+			c.Reflow = map[string]*Reflow{}
+		}
+		if c.Reflow[k] == nil {
+			c.Reflow[k] = &Reflow{}
+		}
+		c.Reflow[k].Literals++
+		c.Reflow[k].Lines += lines
+	}
+}
+
+// oneLine is x as gofmt writes it, if that is on one line.
+func oneLine(fset *token.FileSet, x ast.Node) (string, bool) {
+	var b strings.Builder
+	if err := printer.Fprint(&b, fset, x); err != nil {
+		return "", false
+	}
+	s := b.String()
+	return s, !strings.Contains(s, "\n")
+}
+
+// formatReflow describes c.Reflow for each of ReflowWidths.
+func (c *Count) formatReflow() string {
+	var s []string
+	for _, w := range ReflowWidths {
+		r := c.Reflow[strconv.Itoa(w)]
+		if r == nil {
+			r = &Reflow{}
+		}
+		s = append(s, fmt.Sprintf("%d columns %d (%d lines saved)", w, r.Literals, r.Lines))
+	}
+	return strings.Join(s, ", ")
+}
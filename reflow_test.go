@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCountReflowMixed(t *testing.T) {
+	defer func(v bool) { *allowTypeErrors = v }(*allowTypeErrors)
+	*allowTypeErrors = true
+	dir := writeModule(t, map[string]string{"m.go": `package m
+
+type T struct{ A, B, C int }
+
+func f(A, b int) T {
+	return T{A: A, b,
+		C: 1}
+}
+`})
+	ps, skipped, err := GetPackages(context.Background(), dir, []string{"./..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 1 {
+		t.Fatalf("got %d packages and %d skipped, want 1 package", len(ps), len(skipped))
+	}
+	c := CountPackage(context.Background(), ps[0])
+	if c.MultiLine != 1 || c.Ident.Exact != 1 {
+		t.Errorf("got %d multi-line literals and %d exact, want 1 and 1", c.MultiLine, c.Ident.Exact)
+	}
+}
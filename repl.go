@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Browse runs the repl command: it counts the packages
+// then reads commands, a line at a time, from standard input to move between
+// the packages, the tallies of each file and type within a package,
+// and the individual sites.
+// The prompts and listings are written to standard error, the terminal,
+// and never to the report writer.
+func Browse(ctx context.Context, _ io.Writer, args []string) error {
+	args, err := Patterns(ctx, args)
+	if err != nil {
+		return err
+	}
+	ps, skipped, err := GetPackages(ctx, "", args)
+	if err != nil {
+		return err
+	}
+	LogSkipped(skipped)
+
+	b := &browser{w: os.Stderr, sites: map[string][]*Site{}}
+	for _, p := range ps {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c := CountPackageFunc(ctx, p, func(s *Site) {
+			b.sites[p.ID] = append(b.sites[p.ID], s)
+		})
+		b.pkgs = append(b.pkgs, c)
+	}
+	SortCounts(b.pkgs)
+	return b.run(ctx, os.Stdin)
+}
+
+// browser is the state of the repl command.
+// A nil pkg is the list of packages
+// and a nil list is the package pkg.
+type browser struct {
+	w     io.Writer
+	pkgs  []*Count
+	sites map[string][]*Site
+
+	pkg *Count
+	// files and types are the groups of the sites of pkg.
+	files, types []*Count
+	// list is the sites of a file or type of pkg.
+	list      []*Site
+	listTitle string
+}
+
+const browserHelp = `commands:
+	N      open the Nth package or show the source of the Nth site
+	f N    list the sites in the Nth file of the package
+	t N    list the sites of the Nth type of the package
+	l      list the current view again
+	u      go up a level
+	q      quit
+`
+
+func (b *browser) run(ctx context.Context, r io.Reader) error {
+	in := bufio.NewScanner(r)
+	b.show()
+	for ctx.Err() == nil {
+		fmt.Fprint(b.w, "> ")
+		if !in.Scan() {
+			fmt.Fprintln(b.w)
+			return in.Err()
+		}
+		f := strings.Fields(in.Text())
+		if len(f) == 0 {
+			continue
+		}
+		switch f[0] {
+		case "q":
+			return nil
+		case "?", "h", "help":
+			fmt.Fprint(b.w, browserHelp)
+		case "l":
+			b.show()
+		case "u":
+			b.up()
+			b.show()
+		case "f", "t":
+			if b.pkg == nil || b.list != nil || len(f) != 2 {
+				fmt.Fprintln(b.w, "f and t take a number and only work in a package")
+				continue
+			}
+			groups := b.files
+			if f[0] == "t" {
+				groups = b.types
+			}
+			n, ok := b.index(f[1], len(groups))
+			if !ok {
+				continue
+			}
+			b.openGroup(f[0] == "f", groups[n].ID)
+			b.show()
+		default:
+			n, ok := b.index(f[0], b.length())
+			if !ok {
+				continue
+			}
+			switch {
+			case b.pkg == nil:
+				b.openPackage(b.pkgs[n])
+				b.show()
+			case b.list != nil:
+				b.source(b.list[n])
+			default:
+				fmt.Fprintln(b.w, "use f N or t N in a package")
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// index parses a 1-based index less than or equal to n and returns it 0-based.
+func (b *browser) index(s string, n int) (int, bool) {
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 1 || i > n {
+		fmt.Fprintf(b.w, "%q is not a number from 1 to %d (? for help)\n", s, n)
+		return 0, false
+	}
+	return i - 1, true
+}
+
+func (b *browser) length() int {
+	switch {
+	case b.pkg == nil:
+		return len(b.pkgs)
+	case b.list != nil:
+		return len(b.list)
+	}
+	return 0
+}
+
+func (b *browser) up() {
+	if b.list != nil {
+		b.list = nil
+	} else {
+		b.pkg = nil
+	}
+}
+
+func (b *browser) openPackage(c *Count) {
+	b.pkg = c
+	b.files = sortedGroups(GroupSites(b.sites[c.ID], func(s *Site) string {
+		return s.Pos.Filename
+	}))
+	b.types = sortedGroups(GroupSites(b.sites[c.ID], func(s *Site) string {
+		return s.Type
+	}))
+}
+
+func (b *browser) openGroup(file bool, id string) {
+	b.list = []*Site{}
+	b.listTitle = id
+	for _, s := range b.sites[b.pkg.ID] {
+		if (file && s.Pos.Filename == id) || (!file && s.Type == id) {
+			b.list = append(b.list, s)
+		}
+	}
+}
+
+func sortedGroups(m map[string]*Count) []*Count {
+	var cs []*Count
+	for _, c := range m {
+		cs = append(cs, c)
+	}
+	SortCounts(cs)
+	return cs
+}
+
+// summary is a one line description of c.
+func summary(c *Count) string {
+	m := c.Metrics()
+	return fmt.Sprintf("literals=%d kv=%d exact=%d partial=%d", c.Literals, c.KV, int(m["exact"]), int(m["partial"]))
+}
+
+func (b *browser) show() {
+	switch {
+	case b.pkg == nil:
+		for i, c := range b.pkgs {
+			fmt.Fprintf(b.w, "%4d. %s  %s\n", i+1, c.ID, summary(c))
+		}
+	case b.list != nil:
+		fmt.Fprintf(b.w, "%s in %s:\n", b.listTitle, b.pkg.ID)
+		for i, s := range b.list {
+			fmt.Fprintf(b.w, "%4d. %s %s: %s (%s, %s)\n", i+1, s.Pos, s.Key, s.Value, s.Match.Kind(), s.Match.Result())
+		}
+	default:
+		fmt.Fprint(b.w, b.pkg)
+		fmt.Fprintln(b.w, "files:")
+		for i, c := range b.files {
+			fmt.Fprintf(b.w, "  f %d. %s  %s\n", i+1, c.ID, summary(c))
+		}
+		fmt.Fprintln(b.w, "types:")
+		for i, c := range b.types {
+			fmt.Fprintf(b.w, "  t %d. %s  %s\n", i+1, c.ID, summary(c))
+		}
+	}
+}
+
+// source prints the lines around s.
+func (b *browser) source(s *Site) {
+	bs, err := os.ReadFile(s.Pos.Filename)
+	if err != nil {
+		fmt.Fprintln(b.w, err)
+		return
+	}
+	lines := strings.Split(string(bs), "\n")
+	fmt.Fprintf(b.w, "%s:\n", s.Pos)
+	for i := s.Pos.Line - 3; i <= s.Pos.Line+1; i++ {
+		if i < 0 || i >= len(lines) {
+			continue
+		}
+		mark := " "
+		if i == s.Pos.Line-1 {
+			mark = ">"
+		}
+		fmt.Fprintf(b.w, "%s%5d  %s\n", mark, i+1, lines[i])
+	}
+}
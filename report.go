@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+var (
+	jsonMode JSONMode
+	jsonOut  = &jsonMode.on
+)
+
+func init() {
+	outputFlags.Var(&jsonMode, "json", "write the report as JSON or, with -json=flat, with each count a flat object of dotted keys such as ident.exact, for jq and awk (flat reports cannot be read back by merge, diff, or -baseline)")
+}
+
+// SortCounts sorts cs by ID.
+// Counts with the same ID, such as the same package in two modules of a corpus,
+// keep their order so the output is the same from run to run.
+func SortCounts(cs []*Count) {
+	sort.SliceStable(cs, func(i, j int) bool {
+		return cs[i].ID < cs[j].ID
+	})
+}
+
+// Report is the result of a run.
+type Report struct {
+	// Meta is the run that wrote the report, when written as JSON.
+	Meta     *Meta    `json:"meta,omitempty"`
+	Packages []*Count `json:"packages"`
+	// Modules are subtotals by module, if requested.
+	Modules []*Count `json:"modules,omitempty"`
+	Total   *Count   `json:"total"`
+	// Skipped are the errors of packages not counted because of -errors=report.
+	Skipped []PackageError `json:"skipped,omitempty"`
+	// Sample is how the packages or files were sampled, if they were.
+	Sample *Sample `json:"sample,omitempty"`
+	// Baseline is how the counts changed since -baseline, if given.
+	Baseline *BaselineReport `json:"baseline,omitempty"`
+}
+
+// Write writes r as JSON if -json is set or else as text.
+//
+// The text form omits the total when there is only one package or module,
+// unless -total-always is set, and always with -no-total.
+// With -totals-only, it writes only the total.
+func (r *Report) Write(w io.Writer) error {
+	if *jsonOut {
+		r.Meta = NewMeta()
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		if *totalsOnly {
+			return r.writeTotal(enc)
+		}
+		if jsonMode.flat {
+			f, err := r.flat()
+			if err != nil {
+				return err
+			}
+			return enc.Encode(f)
+		}
+		return enc.Encode(r)
+	}
+	if *totalsOnly {
+		return writeCount(w, r.Total)
+	}
+	counts := append(r.Packages[:len(r.Packages):len(r.Packages)], r.Modules...)
+	if showTotal(len(r.Packages) > 1 || len(r.Modules) > 1) {
+		counts = append(counts, r.Total)
+	}
+	for _, c := range counts {
+		if err := writeCount(w, c); err != nil {
+			return err
+		}
+	}
+	if err := writeSkipped(w, r.Skipped); err != nil {
+		return err
+	}
+	if r.Baseline != nil {
+		if err := writeRemoved(w, r.Baseline.Removed); err != nil {
+			return err
+		}
+	}
+	return writeSample(w, r.Sample)
+}
+
+// writeTotal encodes only the total of r, flattened with -json=flat,
+// with the Meta of r as its meta key.
+func (r *Report) writeTotal(enc *json.Encoder) error {
+	if !jsonMode.flat {
+		return enc.Encode(struct {
+			Meta *Meta `json:"meta"`
+			*Count
+		}{r.Meta, r.Total})
+	}
+	m, err := flatten(r.Total)
+	if err != nil {
+		return err
+	}
+	m["meta"] = r.Meta
+	return enc.Encode(m)
+}
+
+func writeSample(w io.Writer, s *Sample) error {
+	if s == nil || *quiet {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "sampled with -seed %d\n", s.Seed)
+	return err
+}
+
+func writeSkipped(w io.Writer, skipped []PackageError) error {
+	if len(skipped) == 0 {
+		return nil
+	}
+	if *quiet {
+		LogSkipped(skipped)
+		return nil
+	}
+	n := 0
+	for i, e := range skipped {
+		if i == 0 || e.Package != skipped[i-1].Package {
+			n++
+		}
+	}
+	fmt.Fprintf(w, "skipped %d packages with errors:\n", n)
+	for _, e := range skipped {
+		msg := strings.ReplaceAll(e.String(), "\n", "\n\t\t")
+		if _, err := fmt.Fprintf(w, "\t%s: %s\n", e.Package, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadReport reads a report written with -json.
+func ReadReport(name string) (*Report, error) {
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{}
+	if err := json.Unmarshal(bs, r); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if r.Total == nil {
+		return nil, fmt.Errorf("%s: not a report", name)
+	}
+	return r, nil
+}
+
+// MergeReports combines reports, adding counts with the same ID,
+// and adds their totals, which are all there is of reports
+// written without their packages, such as corpus reports.
+func MergeReports(rs []*Report) *Report {
+	merge := func(get func(*Report) []*Count) []*Count {
+		byID := map[string]*Count{}
+		for _, r := range rs {
+			for _, c := range get(r) {
+				m, ok := byID[c.ID]
+				if !ok {
+					m = New(c.ID)
+					byID[c.ID] = m
+				}
+				m.Add(c)
+			}
+		}
+		var out []*Count
+		for _, c := range byID {
+			out = append(out, c)
+		}
+		SortCounts(out)
+		return out
+	}
+
+	m := &Report{
+		Packages: merge(func(r *Report) []*Count { return r.Packages }),
+		Modules:  merge(func(r *Report) []*Count { return r.Modules }),
+		Total:    New("<total>"),
+	}
+	for _, r := range rs {
+		m.Total.Add(r.Total)
+	}
+	return m
+}
+
+// UnmarshalJSON fills in any tallies missing from the JSON with zero tallies
+// so the result can always be added to.
+func (c *Count) UnmarshalJSON(b []byte) error {
+	type plain Count
+	n := New("")
+	if err := json.Unmarshal(b, (*plain)(n)); err != nil {
+		return err
+	}
+	*c = *n
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+// peakRSS returns false where there is no getrusage.
+func peakRSS() (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package main
+
+import (
+	"runtime"
+	"syscall"
+)
+
+// peakRSS returns the most memory resident at once in bytes.
+func peakRSS() (uint64, bool) {
+	var u syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &u); err != nil {
+		return 0, false
+	}
+	// in bytes on macOS and kilobytes elsewhere
+	if runtime.GOOS == "darwin" || runtime.GOOS == "ios" {
+		return uint64(u.Maxrss), true
+	}
+	return uint64(u.Maxrss) * 1024, true
+}
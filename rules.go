@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+)
+
+var matchRules RuleList
+
+func init() {
+	countFlags.Var(&matchRules, "rule", "also score the pairs under each matching `rule`, one of exact, fold, default, prefix, unexported-fold, or another registered with structlit.RegisterRule (may be repeated or comma-separated)")
+}
+
+// RuleList is a flag.Value of comma-separated rule names.
+type RuleList []string
+
+func (l *RuleList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set adds the comma-separated rules in s.
+func (l *RuleList) Set(s string) error {
+	for _, r := range strings.Split(s, ",") {
+		r = strings.TrimSpace(r)
+		if structlit.Rules[r] == nil {
+			return fmt.Errorf("unknown rule %q (one of %s)", r, strings.Join(structlit.RuleNames, ", "))
+		}
+		*l = append(*l, r)
+	}
+	return nil
+}
+
+// scoreRules counts the exact matches of kv in c.Rules under each of the rules
+// and the partial matches in c.RulePartials.
+func (c *Count) scoreRules(kv *ast.KeyValueExpr, info *types.Info, rules []string) {
+	if len(rules) == 0 {
+		return
+	}
+	if c.Rules == nil {
+		c.Rules = map[string]uint64{}
+	}
+	for _, r := range rules {
+		if _, ok := c.Rules[r]; !ok {
+			c.Rules[r] = 0
+		}
+		switch structlit.Rules[r].Match(kv.Key.(*ast.Ident).Name, kv.Value, info) {
+		case structlit.Exact:
+			c.Rules[r]++
+		case structlit.Partial:
+			if c.RulePartials == nil {
+				c.RulePartials = map[string]uint64{}
+			}
+			c.RulePartials[r]++
+		}
+	}
+}
+
+// formatRules lists c.Rules in the order of RuleNames.
+func (c *Count) formatRules() string {
+	var s []string
+	for _, r := range structlit.RuleNames {
+		n, ok := c.Rules[r]
+		if !ok {
+			continue
+		}
+		f := fmt.Sprintf("%s %d (%.1f%%)", r, n, 100*ratio(n, c.KV))
+		if p := c.RulePartials[r]; p > 0 {
+			f += fmt.Sprintf(" and %d partial", p)
+		}
+		s = append(s, f)
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"go/ast"
+	"go/types"
+	"testing"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+)
+
+// suffixMatcher is an exact match when the value is the key
+// and a partial match when it is the key with a trailing underscore.
+type suffixMatcher struct{}
+
+func (suffixMatcher) Match(key string, value ast.Expr, _ *types.Info) structlit.Category {
+	id, _ := value.(*ast.Ident)
+	switch {
+	case id == nil:
+		return structlit.None
+	case id.Name == key:
+		return structlit.Exact
+	case id.Name == key+"_":
+		return structlit.Partial
+	}
+	return structlit.None
+}
+
+func TestRulePartials(t *testing.T) {
+	if structlit.Rules["suffix"] == nil {
+		structlit.RegisterRule("suffix", suffixMatcher{})
+	}
+	defer func(l RuleList) { matchRules = l }(matchRules)
+	matchRules = RuleList{"suffix", "default"}
+	dir := writeModule(t, map[string]string{"m.go": `package m
+
+type T struct{ A, B, C int }
+
+func f(A, B_, c int) T { return T{A: A, B: B_, C: c} }
+`})
+	ps, _, err := GetPackages(context.Background(), dir, []string{"./..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := CountPackage(context.Background(), ps[0])
+	for _, r := range []struct {
+		rule           string
+		exact, partial uint64
+	}{
+		{"suffix", 1, 1},
+		{"default", 1, 1},
+	} {
+		if c.Rules[r.rule] != r.exact || c.RulePartials[r.rule] != r.partial {
+			t.Errorf("%s: got %d exact and %d partial, want %d and %d", r.rule, c.Rules[r.rule], c.RulePartials[r.rule], r.exact, r.partial)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import "path/filepath"
+
+// fileOwners is the package that first counted each file, by its path with symlinks resolved.
+var fileOwners = map[string]string{}
+
+// CountedElsewhere reports whether the file name was already counted
+// by a package other than id, as when the same file is reached
+// through a symlinked directory or a tree of symlinks as bazel makes,
+// or by both a package and its test variant with -test.
+// The files are the same when their paths with symlinks resolved are.
+func CountedElsewhere(id, name string) bool {
+	real, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		// such as a file only in an overlay
+		real = name
+	}
+	owner, ok := fileOwners[real]
+	if !ok {
+		fileOwners[real] = id
+		return false
+	}
+	return owner != id
+}
+
+// ForgetOwners forgets the files counted by the packages ids,
+// as before counting them again after a change,
+// so each counts its files again unless another package still does.
+func ForgetOwners(ids []string) {
+	forget := map[string]bool{}
+	for _, id := range ids {
+		forget[id] = true
+	}
+	for name, owner := range fileOwners {
+		if forget[owner] {
+			delete(fileOwners, name)
+		}
+	}
+}
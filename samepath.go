@@ -0,0 +1,70 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var samePath = NewEnum(countFlags, "same-path", "list", "when packages with different IDs have the same import path, such as a package and its test variant, list each or merge them into one count named by the path", "list", "merge")
+
+// SamePath returns the IDs of the packages in ps by import path
+// for each path that more than one package has.
+func SamePath(ps []*packages.Package) map[string][]string {
+	ids := map[string][]string{}
+	for _, p := range ps {
+		ids[p.PkgPath] = append(ids[p.PkgPath], p.ID)
+	}
+	for path, id := range ids {
+		if len(id) < 2 {
+			delete(ids, path)
+			continue
+		}
+		sort.Strings(id)
+	}
+	return ids
+}
+
+// LogSamePath logs each import path of groups and its IDs.
+func LogSamePath(groups map[string][]string) {
+	var paths []string
+	for path := range groups {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		log.Printf("%s is loaded as each of %s", path, strings.Join(groups[path], ", "))
+	}
+}
+
+// MergeSamePath replaces the counts in cs that are in groups
+// by one count for each import path.
+// A file shared by them is only counted once, by CountedElsewhere,
+// so the merged count is of each file of the path.
+func MergeSamePath(cs []*Count, groups map[string][]string) []*Count {
+	pathOf := map[string]string{}
+	for path, ids := range groups {
+		for _, id := range ids {
+			pathOf[id] = path
+		}
+	}
+	var out []*Count
+	merged := map[string]*Count{}
+	for _, c := range cs {
+		path, ok := pathOf[c.ID]
+		if !ok {
+			out = append(out, c)
+			continue
+		}
+		m, ok := merged[path]
+		if !ok {
+			m = New(path)
+			merged[path] = m
+			out = append(out, m)
+		}
+		m.Add(c)
+	}
+	return out
+}
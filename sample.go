@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"math"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	samplePackages = countFlags.Int("sample", 0, "count only a random sample of `n` of the packages (0 for all)")
+	sampleFiles    = countFlags.Float64("sample-files", 0, "count only a random `fraction` of the files of each package (0 for all)")
+	sampleSeed     = countFlags.Int64("seed", 0, "the `seed` of -sample and -sample-files, which is recorded in the output (0 for the time)")
+)
+
+// Sample records how a report was sampled so it can be reproduced.
+type Sample struct {
+	Seed     int64   `json:"seed"`
+	Packages int     `json:"packages,omitempty"`
+	Files    float64 `json:"files,omitempty"`
+}
+
+// NewSample returns the Sample given by the flags or nil if not sampling.
+// Without -seed the seed is the time, which is logged.
+func NewSample() (*Sample, error) {
+	if *samplePackages == 0 && *sampleFiles == 0 {
+		return nil, nil
+	}
+	if *samplePackages < 0 {
+		return nil, fmt.Errorf("-sample must be at least 0")
+	}
+	if *sampleFiles < 0 || *sampleFiles > 1 {
+		return nil, fmt.Errorf("-sample-files must be from 0 to 1")
+	}
+	s := &Sample{Seed: *sampleSeed, Packages: *samplePackages, Files: *sampleFiles}
+	if s.Seed == 0 {
+		s.Seed = time.Now().UnixNano()
+		log.Printf("sampling with -seed %d", s.Seed)
+	}
+	return s, nil
+}
+
+// sampleKey is the pseudo-random position of name under seed.
+// Choosing by it rather than by a random stream
+// makes the choices independent of the order of loading.
+func sampleKey(seed int64, name string) uint64 {
+	h := fnv.New64a()
+	binary.Write(h, binary.LittleEndian, seed)
+	io.WriteString(h, name)
+	return h.Sum64()
+}
+
+// SamplePackages returns s.Packages of ps, in their order, or all of them if there are no more.
+func (s *Sample) SamplePackages(ps []*packages.Package) []*packages.Package {
+	if s == nil || s.Packages == 0 || s.Packages >= len(ps) {
+		return ps
+	}
+	keys := make([]uint64, len(ps))
+	order := make([]int, len(ps))
+	for i, p := range ps {
+		keys[i] = sampleKey(s.Seed, p.ID)
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return keys[order[i]] < keys[order[j]]
+	})
+	order = order[:s.Packages]
+	sort.Ints(order)
+	var keep []*packages.Package
+	for _, i := range order {
+		keep = append(keep, ps[i])
+	}
+	return keep
+}
+
+// keepFile reports whether the file name of the package id is in the -sample-files sample.
+// Only the base name is used so the sample is the same in any checkout.
+func (s *Sample) keepFile(id, name string) bool {
+	if s == nil || s.Files == 0 {
+		return true
+	}
+	return float64(sampleKey(s.Seed, id+"/"+filepath.Base(name))) < s.Files*math.MaxUint64
+}
+
+// sample is the sample of the current run, if any.
+var sample *Sample
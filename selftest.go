@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// selftestFiles are the fixtures of the selftest command.
+// Each counted pair is on its own line with a comment
+// giving the Kind and Result it is expected to have:
+//
+//	Name: name, // want ident partial
+//
+//go:embed testdata/selftest/*.go
+var selftestFiles embed.FS
+
+// SelfTest runs the selftest command: it counts the embedded fixtures
+// and reports every pair not classified as the fixture expects,
+// and every suggested fix of the analyzer that, once applied, does not type check
+// or changes the value of a pair,
+// so a build of the tool can be checked before trusting its numbers.
+func SelfTest(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("selftest: takes no arguments")
+	}
+	names, err := fs.Glob(selftestFiles, "testdata/selftest/*.go")
+	if err != nil {
+		return err
+	}
+	pairs, fixes, failed, broken := 0, 0, 0, 0
+	for _, name := range names {
+		src, err := selftestFiles.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		p, err := ReadFilePackage(bytes.NewReader(src), path.Base(name))
+		if err != nil {
+			return err
+		}
+
+		want := map[int]string{}
+		for _, g := range p.Syntax[0].Comments {
+			for _, c := range g.List {
+				if e, ok := strings.CutPrefix(c.Text, "// want "); ok {
+					want[p.Fset.Position(c.Pos()).Line] = e
+				}
+			}
+		}
+		got := map[int]string{}
+		CountPackageFunc(ctx, p, func(s *Site) {
+			got[s.Pos.Line] = s.Match.Kind() + " " + s.Match.Result()
+		})
+
+		var lines []int
+		for l := range want {
+			lines = append(lines, l)
+		}
+		for l := range got {
+			if _, ok := want[l]; !ok {
+				lines = append(lines, l)
+			}
+		}
+		sort.Ints(lines)
+		for _, l := range lines {
+			pairs++
+			g, e := got[l], want[l]
+			if g == e {
+				continue
+			}
+			failed++
+			if g == "" {
+				g = "not counted"
+			}
+			if e == "" {
+				e = "not counted"
+			}
+			fmt.Fprintf(w, "%s:%d: got %s, want %s\n", name, l, g, e)
+		}
+
+		n, problems, err := checkFixes(name, src, p)
+		if err != nil {
+			return err
+		}
+		fixes += n
+		broken += len(problems)
+		for _, msg := range problems {
+			fmt.Fprintln(w, msg)
+		}
+	}
+	if failed > 0 || broken > 0 {
+		return fmt.Errorf("selftest: %d of %d pairs misclassified and %d problems with %d suggested fixes", failed, pairs, broken, fixes)
+	}
+	fmt.Fprintf(w, "ok: %d pairs and %d suggested fixes in %d files\n", pairs, fixes, len(names))
+	return nil
+}
+
+// checkFixes applies the suggested fixes of structlit.Analyzer to the fixture src, parsed as p,
+// and returns the number of fixes and how the result fails to type check
+// or to give each pair the same value.
+func checkFixes(name string, src []byte, p *packages.Package) (int, []string, error) {
+	fixes := 0
+	var edits []Edit
+	pass := &analysis.Pass{
+		Analyzer:  structlit.Analyzer,
+		Fset:      p.Fset,
+		Files:     p.Syntax,
+		Pkg:       p.Types,
+		TypesInfo: p.TypesInfo,
+		Report: func(d analysis.Diagnostic) {
+			for _, fix := range d.SuggestedFixes {
+				fixes++
+				for _, e := range fix.TextEdits {
+					edits = append(edits, Edit{p.Fset.Position(e.Pos).Offset, p.Fset.Position(e.End).Offset, string(e.NewText)})
+				}
+			}
+		},
+	}
+	if _, err := structlit.Analyzer.Run(pass); err != nil {
+		return 0, nil, err
+	}
+	if fixes == 0 {
+		return 0, nil, nil
+	}
+	fixed, err := ReadFilePackage(strings.NewReader(ApplyEdits(string(src), edits)), path.Base(name))
+	if err != nil {
+		return fixes, []string{fmt.Sprintf("%s: fixed: %v", name, err)}, nil
+	}
+	var problems []string
+	for _, e := range fixed.TypeErrors {
+		problems = append(problems, fmt.Sprintf("%s: fixed: %v", name, e))
+	}
+	// a rename keeps the length of the name, so the positions are the same
+	before, after := pairValues(p), pairValues(fixed)
+	for pos, decl := range before {
+		if after[pos] != decl {
+			problems = append(problems, fmt.Sprintf("%s:%d: fixed: the value of the pair changed", name, pos.Line))
+		}
+	}
+	sort.Strings(problems)
+	return fixes, problems, nil
+}
+
+// pairValues returns the position of the declaration of the variable of p
+// that is the value of each pair, by the position of the pair.
+func pairValues(p *packages.Package) map[token.Position]token.Position {
+	values := map[token.Position]token.Position{}
+	for _, f := range p.Syntax {
+		structlit.StructLits(p.TypesInfo, f, func(_ []ast.Node, _ *ast.CompositeLit, _ types.Type, kvs []*ast.KeyValueExpr) {
+			for _, kv := range kvs {
+				v, ok := p.TypesInfo.Uses[structlit.ValueIdent(kv.Value)].(*types.Var)
+				if ok && v.Pkg() == p.Types {
+					values[p.Fset.Position(kv.Pos())] = p.Fset.Position(v.Pos())
+				}
+			}
+		})
+	}
+	return values
+}
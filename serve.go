@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var serveAddr = serveFlags.String("addr", "localhost:8080", "serve the dashboard on `addr`")
+
+// Serve runs the serve command: it counts the packages
+// then serves a dashboard of the results over HTTP until interrupted.
+func Serve(ctx context.Context, w io.Writer, args []string) error {
+	args, err := Patterns(ctx, args)
+	if err != nil {
+		return err
+	}
+	ps, skipped, err := GetPackages(ctx, "", args)
+	if err != nil {
+		return err
+	}
+	LogSkipped(skipped)
+
+	d := &dashboard{
+		sites: map[string][]*Site{},
+		files: map[string]bool{},
+		total: New("<total>"),
+	}
+	for _, p := range ps {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c := CountPackageFunc(ctx, p, func(s *Site) {
+			d.sites[p.ID] = append(d.sites[p.ID], s)
+			d.files[s.Pos.Filename] = true
+		})
+		d.pkgs = append(d.pkgs, c)
+		d.total.Add(c)
+	}
+	SortCounts(d.pkgs)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.index)
+	mux.HandleFunc("/package", d.pkg)
+	mux.HandleFunc("/source", d.source)
+	mux.Handle("/debug/vars", expvar.Handler())
+	if *serveAPI {
+		d.api(mux)
+	}
+
+	ln, err := net.Listen("tcp", *serveAddr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: mux}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	fmt.Fprintf(w, "serving on http://%s\n", ln.Addr())
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// dashboard holds the results for the serve command.
+type dashboard struct {
+	pkgs  []*Count
+	total *Count
+	sites map[string][]*Site
+	// files are the files that may be shown by /source.
+	files map[string]bool
+}
+
+// row is a Count as shown in a table.
+type row struct {
+	*Count
+	Exact, Partial, NoMatch uint64
+	Ratio                   float64
+}
+
+func newRow(c *Count) row {
+	m := c.Metrics()
+	return row{
+		Count:   c,
+		Exact:   uint64(m["exact"]),
+		Partial: uint64(m["partial"]),
+		NoMatch: uint64(m["no_match"]),
+		Ratio:   m["exact_ratio"],
+	}
+}
+
+// rows filters cs by q and sorts them by the named column.
+func rows(cs []*Count, q, by string) []row {
+	var rs []row
+	for _, c := range cs {
+		if q == "" || strings.Contains(c.ID, q) {
+			rs = append(rs, newRow(c))
+		}
+	}
+	key := func(r row) float64 {
+		switch by {
+		case "literals":
+			return float64(r.Literals)
+		case "kv":
+			return float64(r.KV)
+		case "exact":
+			return float64(r.Exact)
+		case "partial":
+			return float64(r.Partial)
+		case "no_match":
+			return float64(r.NoMatch)
+		case "ratio":
+			return r.Ratio
+		}
+		return 0
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		// ties, and every row when sorting by ID, are in ID order
+		if key(rs[i]) == key(rs[j]) {
+			return rs[i].ID < rs[j].ID
+		}
+		return key(rs[i]) > key(rs[j])
+	})
+	return rs
+}
+
+func (d *dashboard) index(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	q, by := r.FormValue("q"), r.FormValue("sort")
+	d.render(w, "index", map[string]any{
+		"Q":     q,
+		"Sort":  by,
+		"Rows":  rows(d.pkgs, q, by),
+		"Total": newRow(d.total),
+	})
+}
+
+func (d *dashboard) pkg(w http.ResponseWriter, r *http.Request) {
+	id := r.FormValue("id")
+	var c *Count
+	for _, p := range d.pkgs {
+		if p.ID == id {
+			c = p
+		}
+	}
+	if c == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	sites := d.sites[id]
+	match := r.FormValue("match")
+	if match != "" {
+		var keep []*Site
+		for _, s := range sites {
+			if s.Match.Result() == match {
+				keep = append(keep, s)
+			}
+		}
+		sites = keep
+	}
+	files := GroupSites(d.sites[id], func(s *Site) string { return s.Pos.Filename })
+	types := GroupSites(d.sites[id], func(s *Site) string { return s.Type })
+	d.render(w, "package", map[string]any{
+		"Count": c,
+		"Row":   newRow(c),
+		"Match": match,
+		"Files": rows(sortedGroups(files), "", ""),
+		"Types": rows(sortedGroups(types), "", ""),
+		"Sites": sites,
+	})
+}
+
+func (d *dashboard) source(w http.ResponseWriter, r *http.Request) {
+	name := r.FormValue("file")
+	// only show files that were analyzed
+	if !d.files[name] {
+		http.NotFound(w, r)
+		return
+	}
+	bs, err := os.ReadFile(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	line, _ := strconv.Atoi(r.FormValue("line"))
+	d.render(w, "source", map[string]any{
+		"File":  name,
+		"Line":  line,
+		"Lines": strings.Split(string(bs), "\n"),
+	})
+}
+
+func (d *dashboard) render(w http.ResponseWriter, name string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.ExecuteTemplate(w, name, data); err != nil {
+		log.Println(err)
+	}
+}
+
+var dashboardTemplate = template.Must(template.New("").Funcs(template.FuncMap{
+	"percent": func(f float64) string {
+		return strconv.FormatFloat(100*f, 'f', 1, 64)
+	},
+	"inc": func(i int) int {
+		return i + 1
+	},
+	"dict": func(kv ...any) map[string]any {
+		m := map[string]any{}
+		for i := 0; i+1 < len(kv); i += 2 {
+			m[kv[i].(string)] = kv[i+1]
+		}
+		m["Columns"] = []struct{ Key, Name string }{
+			{"id", m["Title"].(string)},
+			{"literals", "literals"},
+			{"kv", "KV pairs"},
+			{"exact", "exact"},
+			{"partial", "partial"},
+			{"no_match", "no match"},
+			{"ratio", "exact / KV"},
+		}
+		return m
+	},
+}).Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>keyed struct literals</title>
+<style>
+body { font-family: sans-serif; margin: 1em 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 2px 8px; text-align: right; border-bottom: 1px solid #ddd; }
+th:first-child, td:first-child { text-align: left; }
+.bar { background: #4a8; height: 10px; }
+.barbox { width: 120px; background: #eee; }
+.exact { color: #282; } .partial { color: #a60; } .none { color: #888; }
+pre .hl { background: #ffa; }
+</style></head><body>{{end}}
+
+{{define "bar"}}<td class="barbox"><div class="bar" style="width: {{percent .}}%"></div></td>{{end}}
+
+{{define "table"}}
+<table>
+<tr>{{$q := .Q}}{{$link := .Link}}{{range .Columns}}<th{{if eq .Key "ratio"}} colspan="2"{{end}}>{{if $link}}<a href="?q={{$q}}&sort={{.Key}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</th>{{end}}</tr>
+{{range .Rows}}<tr><td>{{if $.Link}}<a href="/package?id={{.ID}}">{{.ID}}</a>{{else}}{{.ID}}{{end}}</td>
+<td>{{.Literals}}</td><td>{{.KV}}</td><td>{{.Exact}}</td><td>{{.Partial}}</td><td>{{.NoMatch}}</td>
+<td>{{percent .Ratio}}%</td>{{template "bar" .Ratio}}</tr>
+{{end}}</table>
+{{end}}
+
+{{define "index"}}{{template "head"}}
+<h1>keyed struct literals</h1>
+<form><input name="q" value="{{.Q}}" placeholder="filter packages"><input type="hidden" name="sort" value="{{.Sort}}"> <button>filter</button></form>
+<h2>total</h2>
+<p>{{.Total.Literals}} literals, {{.Total.KV}} KV pairs, {{.Total.Exact}} exact ({{percent .Total.Ratio}}%), {{.Total.Partial}} partial</p>
+<h2>packages</h2>
+{{template "table" (dict "Title" "package" "Q" .Q "Rows" .Rows "Link" true)}}
+</body></html>{{end}}
+
+{{define "package"}}{{template "head"}}
+<p><a href="/">all packages</a></p>
+<h1>{{.Count.ID}}</h1>
+<pre>{{.Count}}</pre>
+<h2>files</h2>
+{{template "table" (dict "Title" "file" "Rows" .Files)}}
+<h2>types</h2>
+{{template "table" (dict "Title" "type" "Rows" .Types)}}
+<h2>sites</h2>
+<p>show: <a href="?id={{.Count.ID}}">all</a> <a href="?id={{.Count.ID}}&match=exact">exact</a> <a href="?id={{.Count.ID}}&match=partial">partial</a> <a href="?id={{.Count.ID}}&match=none">no match</a></p>
+<table>
+<tr><th>position</th><th>pair</th><th>type</th><th>kind</th><th>match</th></tr>
+{{range .Sites}}<tr><td><a href="/source?file={{.Pos.Filename}}&line={{.Pos.Line}}#L{{.Pos.Line}}">{{.Pos}}</a></td>
+<td><code>{{.Key}}: {{.Value}}</code></td><td>{{.Type}}</td><td>{{.Match.Kind}}</td><td class="{{.Match.Result}}">{{.Match.Result}}</td></tr>
+{{end}}</table>
+</body></html>{{end}}
+
+{{define "source"}}{{template "head"}}
+<h1>{{.File}}</h1>
+<pre>{{range $i, $l := .Lines}}<span id="L{{inc $i}}"{{if eq (inc $i) $.Line}} class="hl"{{end}}>{{printf "%5d" (inc $i)}}  {{$l}}</span>
+{{end}}</pre>
+</body></html>{{end}}
+`))
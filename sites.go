@@ -0,0 +1,103 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+	"golang.org/x/tools/go/packages"
+)
+
+// Site is a single KV pair in a counted literal.
+type Site struct {
+	Package string
+	Pos     token.Position
+	// Literal is the position of the literal containing the pair.
+	Literal token.Position
+	Key     string
+	Value   string
+	// ValuePos is the position of the start of the value.
+	ValuePos token.Position
+	// Type is the type of the literal.
+	Type string
+	// FieldType is the type of the field of the key, or "" if there is none.
+	FieldType string
+	// ValueKind is the syntax of the value, from ValueKind.
+	ValueKind string
+	Match     *structlit.Match
+}
+
+// NewSite returns the Site of kv in the literal lit of type typ.
+func NewSite(p *packages.Package, lit *ast.CompositeLit, kv *ast.KeyValueExpr, typ types.Type, m *structlit.Match) *Site {
+	key, fieldType := "", ""
+	if id, ok := kv.Key.(*ast.Ident); ok {
+		key = id.Name
+		if v, ok := p.TypesInfo.Uses[id].(*types.Var); ok {
+			fieldType = v.Type().String()
+		}
+	}
+	return &Site{
+		Package:   p.ID,
+		Pos:       p.Fset.Position(kv.Pos()),
+		Literal:   p.Fset.Position(lit.Pos()),
+		Key:       key,
+		Value:     types.ExprString(kv.Value),
+		ValuePos:  p.Fset.Position(kv.Value.Pos()),
+		Type:      typ.String(),
+		FieldType: fieldType,
+		ValueKind: ValueKind(kv.Value),
+		Match:     m,
+	}
+}
+
+// ValueKind names the syntax of the value x of a pair:
+// ident, selector, star, amp, call, literal (a basic literal),
+// composite, func, or other. Parentheses are ignored.
+func ValueKind(x ast.Expr) string {
+	switch x := x.(type) {
+	case *ast.Ident:
+		return "ident"
+	case *ast.SelectorExpr:
+		return "selector"
+	case *ast.StarExpr:
+		return "star"
+	case *ast.UnaryExpr:
+		if x.Op == token.AND {
+			return "amp"
+		}
+	case *ast.CallExpr:
+		return "call"
+	case *ast.BasicLit:
+		return "literal"
+	case *ast.CompositeLit:
+		return "composite"
+	case *ast.FuncLit:
+		return "func"
+	case *ast.ParenExpr:
+		return ValueKind(x.X)
+	}
+	return "other"
+}
+
+// GroupSites counts sites by the string key returns for each,
+// giving a Count with that ID for every key.
+func GroupSites(sites []*Site, key func(*Site) string) map[string]*Count {
+	groups := map[string]*Count{}
+	lits := map[string]map[token.Position]bool{}
+	for _, s := range sites {
+		k := key(s)
+		c, ok := groups[k]
+		if !ok {
+			c = New(k)
+			groups[k] = c
+			lits[k] = map[token.Position]bool{}
+		}
+		c.Count(s.Match)
+		if !lits[k][s.Literal] {
+			lits[k][s.Literal] = true
+			c.Literals++
+		}
+	}
+	return groups
+}
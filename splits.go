@@ -0,0 +1,384 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+	"golang.org/x/tools/go/packages"
+)
+
+// A Split divides the literals into named buckets.
+type Split struct {
+	Name string
+	// Bucket returns the bucket of the literal c in the file f of p.
+	// The path is the nodes enclosing c from f down.
+	Bucket func(p *packages.Package, f *ast.File, path []ast.Node, c *ast.CompositeLit) string
+	// Order, if set, is the order to list the buckets in
+	// instead of sorted by name.
+	Order []string
+}
+
+// SplitNames are the splits in the order they are reported.
+var SplitNames []string
+
+// Splits are the splits by name.
+var Splits = map[string]*Split{}
+
+// RegisterSplit adds a split that -split can name.
+func RegisterSplit(s *Split) {
+	SplitNames = append(SplitNames, s.Name)
+	Splits[s.Name] = s
+}
+
+func init() {
+	RegisterSplit(&Split{
+		Name: "generated",
+		Bucket: func(_ *packages.Package, f *ast.File, _ []ast.Node, _ *ast.CompositeLit) string {
+			if IsGenerated(f) {
+				return "generated"
+			}
+			return "handwritten"
+		},
+	})
+	RegisterSplit(&Split{
+		Name: "generator",
+		Bucket: func(_ *packages.Package, f *ast.File, _ []ast.Node, _ *ast.CompositeLit) string {
+			g, ok := Generator(f)
+			switch {
+			case !ok:
+				return "handwritten"
+			case g == "":
+				return "unknown generator"
+			}
+			return g
+		},
+	})
+	RegisterSplit(&Split{
+		Name: "closure",
+		Bucket: func(_ *packages.Package, _ *ast.File, path []ast.Node, _ *ast.CompositeLit) string {
+			for _, n := range path {
+				if _, ok := n.(*ast.FuncLit); ok {
+					return "closure"
+				}
+			}
+			return "not closure"
+		},
+	})
+	RegisterSplit(&Split{
+		Name:   "element",
+		Bucket: elementBucket,
+	})
+	RegisterSplit(&Split{
+		Name: "decl",
+		Bucket: func(_ *packages.Package, _ *ast.File, path []ast.Node, _ *ast.CompositeLit) string {
+			// path[0] is the file and path[1] the top-level declaration
+			switch d := path[1].(type) {
+			case *ast.GenDecl:
+				return "package var"
+			case *ast.FuncDecl:
+				if d.Recv == nil && d.Name.Name == "init" {
+					return "init"
+				}
+			}
+			return "function"
+		},
+	})
+	RegisterSplit(&Split{
+		Name: "constructor",
+		Bucket: func(p *packages.Package, _ *ast.File, path []ast.Node, c *ast.CompositeLit) string {
+			if d, ok := path[1].(*ast.FuncDecl); ok && IsConstructor(p.TypesInfo, d, structlit.LitType(p.TypesInfo, c)) {
+				return "constructor"
+			}
+			return "not constructor"
+		},
+	})
+	RegisterSplit(&Split{
+		Name: "fields",
+		Bucket: func(p *packages.Package, _ *ast.File, _ []ast.Node, c *ast.CompositeLit) string {
+			n := structlit.StructOf(structlit.LitType(p.TypesInfo, c)).NumFields()
+			switch {
+			case n == 1:
+				return "1 field"
+			case n <= 3:
+				return "2-3 fields"
+			case n <= 7:
+				return "4-7 fields"
+			case n <= 15:
+				return "8-15 fields"
+			}
+			return "16+ fields"
+		},
+		Order: []string{"1 field", "2-3 fields", "4-7 fields", "8-15 fields", "16+ fields"},
+	})
+	RegisterSplit(&Split{
+		Name: "origin",
+		Bucket: func(p *packages.Package, _ *ast.File, _ []ast.Node, c *ast.CompositeLit) string {
+			named, ok := structlit.LitType(p.TypesInfo, c).(*types.Named)
+			switch {
+			case !ok:
+				return "unnamed"
+			case named.Obj().Pkg() == p.Types:
+				return "same package"
+			}
+			return "imported"
+		},
+	})
+	RegisterSplit(&Split{
+		Name: "options",
+		Bucket: func(p *packages.Package, _ *ast.File, _ []ast.Node, c *ast.CompositeLit) string {
+			if IsOptionsStruct(structlit.LitType(p.TypesInfo, c)) {
+				return "options struct"
+			}
+			return "not options struct"
+		},
+	})
+	RegisterSplit(&Split{
+		Name: "layout",
+		Bucket: func(p *packages.Package, _ *ast.File, _ []ast.Node, c *ast.CompositeLit) string {
+			if IsMultiLine(p.Fset, c) {
+				return "multi-line"
+			}
+			return "single-line"
+		},
+		Order: []string{"single-line", "multi-line"},
+	})
+	RegisterSplit(&Split{
+		Name:   "testfunc",
+		Bucket: testFuncBucket,
+		Order:  []string{"test", "benchmark", "fuzz", "example", "not test func"},
+	})
+	RegisterSplit(&Split{
+		Name: "alias",
+		Bucket: func(p *packages.Package, _ *ast.File, _ []ast.Node, c *ast.CompositeLit) string {
+			if IsAlias(p.TypesInfo, c) {
+				return "alias"
+			}
+			return "not alias"
+		},
+	})
+}
+
+// IsConstructor reports whether d is a function, not a method,
+// named New or New followed by anything but a lowercase letter, as in NewServer but not Newline,
+// or returning a single value of type typ or *typ.
+func IsConstructor(info *types.Info, d *ast.FuncDecl, typ types.Type) bool {
+	if d.Recv != nil {
+		return false
+	}
+	if isTestName(d.Name.Name, "New") {
+		return true
+	}
+	res := d.Type.Results
+	if res == nil || len(res.List) != 1 || len(res.List[0].Names) > 1 {
+		return false
+	}
+	r := info.TypeOf(res.List[0].Type)
+	if ptr, ok := r.(*types.Pointer); ok {
+		r = ptr.Elem()
+	}
+	return r != nil && types.Identical(r, typ)
+}
+
+// elementBucket returns whether c is an element, or &element,
+// of a slice, array, or map literal, such as a table of test cases.
+func elementBucket(p *packages.Package, _ *ast.File, path []ast.Node, _ *ast.CompositeLit) string {
+	i := len(path) - 1
+	if u, ok := path[i].(*ast.UnaryExpr); ok && u.Op == token.AND {
+		i--
+	}
+	// a map key or value or an indexed array element
+	if _, ok := path[i].(*ast.KeyValueExpr); ok {
+		i--
+	}
+	if outer, ok := path[i].(*ast.CompositeLit); ok && p.TypesInfo.Types[outer].Type != nil {
+		switch p.TypesInfo.Types[outer].Type.Underlying().(type) {
+		case *types.Slice, *types.Array:
+			return "slice element"
+		case *types.Map:
+			return "map element"
+		}
+	}
+	return "not element"
+}
+
+// IsMultiLine reports whether the braces of c are on different lines.
+func IsMultiLine(fset *token.FileSet, c *ast.CompositeLit) bool {
+	return fset.Position(c.Lbrace).Line != fset.Position(c.Rbrace).Line
+}
+
+// testFuncBucket returns the kind of function in a _test.go file
+// that go test runs, if c is within one, such as "example" for ExampleF.
+func testFuncBucket(p *packages.Package, f *ast.File, path []ast.Node, _ *ast.CompositeLit) string {
+	const none = "not test func"
+	d, ok := path[1].(*ast.FuncDecl)
+	if !ok || d.Recv != nil || !strings.HasSuffix(p.Fset.Position(f.Package).Filename, "_test.go") {
+		return none
+	}
+	for _, k := range []struct{ prefix, bucket string }{
+		{"Test", "test"},
+		{"Benchmark", "benchmark"},
+		{"Fuzz", "fuzz"},
+		{"Example", "example"},
+	} {
+		if isTestName(d.Name.Name, k.prefix) {
+			return k.bucket
+		}
+	}
+	return none
+}
+
+// isTestName reports whether name is prefix alone or followed by
+// something other than a lower case letter, as go test requires.
+func isTestName(name, prefix string) bool {
+	rest, ok := strings.CutPrefix(name, prefix)
+	if !ok {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return !unicode.IsLower(r)
+}
+
+var splitBy SplitList
+
+// after the splits are registered so they can be listed
+func init() {
+	countFlags.Var(&splitBy, "split", "also count the literals in buckets along each `dimension`, one of "+strings.Join(SplitNames, ", ")+" (may be repeated or comma-separated)")
+}
+
+var generatedRE = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// IsGenerated reports whether f has the comment
+// marking generated code before its package clause.
+func IsGenerated(f *ast.File) bool {
+	_, ok := Generator(f)
+	return ok
+}
+
+// Generator reports whether f is generated and, if its comment says,
+// the name of the program that generated it,
+// such as protoc-gen-go for "// Code generated by protoc-gen-go. DO NOT EDIT."
+// The name is the last element of a path, without .exe, and lower case,
+// so github.com/golang/mock/mockgen and MockGen are both mockgen.
+func Generator(f *ast.File) (string, bool) {
+	for _, g := range f.Comments {
+		if g.Pos() > f.Package {
+			break
+		}
+		for _, c := range g.List {
+			if !generatedRE.MatchString(c.Text) {
+				continue
+			}
+			_, by, ok := strings.Cut(c.Text, "// Code generated by ")
+			if !ok {
+				return "", true
+			}
+			name := strings.Fields(strings.TrimSuffix(by, "DO NOT EDIT."))
+			if len(name) == 0 {
+				return "", true
+			}
+			gen := strings.Trim(name[0], ".,;:\"'`")
+			gen = strings.TrimSuffix(path.Base(strings.ReplaceAll(gen, "\\", "/")), ".exe")
+			return strings.ToLower(gen), true
+		}
+	}
+	return "", false
+}
+
+// SplitList is a flag.Value of comma-separated split names.
+type SplitList []string
+
+func (l *SplitList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set adds the comma-separated splits in s.
+func (l *SplitList) Set(s string) error {
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if Splits[name] == nil {
+			return fmt.Errorf("unknown split %q (one of %s)", name, strings.Join(SplitNames, ", "))
+		}
+		*l = append(*l, name)
+	}
+	return nil
+}
+
+// bucket returns the count for the bucket of split, creating it if needed.
+func (c *Count) bucket(split, bucket string) *Count {
+	if c.Splits == nil {
+		c.Splits = map[string]map[string]*Count{}
+	}
+	if c.Splits[split] == nil {
+		c.Splits[split] = map[string]*Count{}
+	}
+	b := c.Splits[split][bucket]
+	if b == nil {
+		b = New(bucket)
+		c.Splits[split][bucket] = b
+	}
+	return b
+}
+
+// addSplits adds the buckets of o to c.
+func (c *Count) addSplits(o *Count) {
+	for split, buckets := range o.Splits {
+		for name, b := range buckets {
+			c.bucket(split, name).Add(b)
+		}
+	}
+}
+
+// writeSplits writes a table of the buckets of each split
+// with each bucket's share of the exact matches.
+func (c *Count) writeSplits(w io.Writer) {
+	var names []string
+	for name := range c.Splits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	exact := c.Metrics()["exact"]
+	for _, split := range names {
+		var buckets []*Count
+		for _, b := range c.Splits[split] {
+			buckets = append(buckets, b)
+		}
+		order := map[string]int{}
+		if s := Splits[split]; s != nil {
+			for i, name := range s.Order {
+				order[name] = i + 1
+			}
+		}
+		sort.Slice(buckets, func(i, j int) bool {
+			oi, oj := order[buckets[i].ID], order[buckets[j].ID]
+			if oi != oj {
+				return oi < oj
+			}
+			return buckets[i].ID < buckets[j].ID
+		})
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(tw, "by %s\tliterals\tKV pairs\texact\tpartial\tshare of exact\n", split)
+		for _, b := range buckets {
+			m := b.Metrics()
+			share := 0.0
+			if exact > 0 {
+				share = m["exact"] / exact
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.1f%%\n", b.ID, b.Literals, b.KV, int(m["exact"]), int(m["partial"]), 100*share)
+		}
+		tw.Flush()
+	}
+}
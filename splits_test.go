@@ -0,0 +1,65 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+func TestIsConstructor(t *testing.T) {
+	const src = `package m
+
+type T struct{ A int }
+
+type B struct{}
+
+func New() {}
+func NewT() {}
+func New_() {}
+func Newline() {}
+func Newsletter() {}
+func (B) NewT() {}
+func (B) Build() *T { return nil }
+func build() *T { return nil }
+func value() T { return T{} }
+func other() int { return 0 }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "m.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{Types: map[ast.Expr]types.TypeAndValue{}, Defs: map[*ast.Ident]types.Object{}, Uses: map[*ast.Ident]types.Object{}}
+	pkg, err := new(types.Config).Check("m", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ := pkg.Scope().Lookup("T").Type()
+	want := map[string]bool{
+		"New":        true,
+		"NewT":       true,
+		"New_":       true,
+		"Newline":    false,
+		"Newsletter": false,
+		"B.NewT":     false,
+		"B.Build":    false,
+		"build":      true,
+		"value":      true,
+		"other":      false,
+	}
+	for _, d := range f.Decls {
+		d, ok := d.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		name := d.Name.Name
+		if d.Recv != nil {
+			name = "B." + name
+		}
+		if got := IsConstructor(info, d, typ); got != want[name] {
+			t.Errorf("IsConstructor(%s) = %v, want %v", name, got, want[name])
+		}
+	}
+}
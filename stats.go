@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	showStats = commonFlags.Bool("stats", false, "at the end of the run, write the time spent loading and counting, files per second, the slowest packages, and peak memory to stderr")
+	statsTop  = commonFlags.Int("stats-top", 10, "with -stats, list the `n` slowest packages to count")
+)
+
+// packageTime is the time counting a package took.
+type packageTime struct {
+	id    string
+	files int
+	d     time.Duration
+}
+
+// runStats are the timings of the run for -stats.
+var runStats struct {
+	sync.Mutex
+	loads    int
+	load     time.Duration
+	packages int
+	files    int
+	count    time.Duration
+	// times are of every package counted, in the order they were done.
+	times []packageTime
+}
+
+// timeLoad records a call to packages.Load that started at start.
+func timeLoad(start time.Time) {
+	d := time.Since(start)
+	runStats.Lock()
+	defer runStats.Unlock()
+	runStats.loads++
+	runStats.load += d
+}
+
+// timeCount records counting p, which started at start.
+func timeCount(p *packages.Package, start time.Time) {
+	recordCount(p.ID, len(p.Syntax), time.Since(start))
+}
+
+// recordCount records counting the package id of files in d.
+func recordCount(id string, files int, d time.Duration) {
+	runStats.Lock()
+	defer runStats.Unlock()
+	runStats.packages++
+	runStats.files += files
+	runStats.count += d
+	runStats.times = append(runStats.times, packageTime{id, files, d})
+}
+
+// slowest returns the n packages that took longest to count, slowest first.
+func slowest(times []packageTime, n int) []packageTime {
+	times = append([]packageTime(nil), times...)
+	sort.SliceStable(times, func(i, j int) bool { return times[i].d > times[j].d })
+	if n = max(n, 0); len(times) > n {
+		times = times[:n]
+	}
+	return times
+}
+
+// WriteStats writes the timings of the run and its peak memory use,
+// then the -stats-top slowest packages.
+func WriteStats(w io.Writer) error {
+	s := &runStats
+	s.Lock()
+	defer s.Unlock()
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	w = tw
+	round := func(d time.Duration) time.Duration {
+		if d < 10*time.Millisecond {
+			return d.Round(time.Microsecond)
+		}
+		return d.Round(time.Millisecond)
+	}
+	fmt.Fprintf(w, "stats:\n  total:\t%v\n", round(time.Since(started)))
+	fmt.Fprintf(w, "  load:\t%v in %d calls to go list\n", round(s.load), s.loads)
+	fmt.Fprintf(w, "  count:\t%v for %d packages and %d files", round(s.count), s.packages, s.files)
+	if s.packages > 0 {
+		fmt.Fprintf(w, ", %v per package", round(s.count/time.Duration(s.packages)))
+	}
+	fmt.Fprintln(w)
+	if hits := cacheHits.Value(); hits > 0 {
+		fmt.Fprintf(w, "  cached:\t%d packages\n", hits)
+	}
+	if secs := s.count.Seconds(); secs > 0 {
+		fmt.Fprintf(w, "  files/sec:\t%.0f\n", float64(s.files)/secs)
+	}
+	if rss, ok := peakRSS(); ok {
+		fmt.Fprintf(w, "  peak RSS:\t%.1f MiB\n", float64(rss)/(1<<20))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	// a block of its own so the package IDs do not widen the lines above
+	top := slowest(s.times, *statsTop)
+	if len(top) == 0 {
+		return nil
+	}
+	fmt.Fprintf(tw, "  slowest:\n")
+	for _, t := range top {
+		fmt.Fprintf(tw, "    %s\t%v\t%d files\n", t.id, round(t.d), t.files)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteStatsSlowest(t *testing.T) {
+	defer func(n int) { *statsTop = n }(*statsTop)
+	defer func(times []packageTime) { runStats.times = times }(runStats.times)
+	runStats.times = nil
+	recordCount("fast", 1, time.Millisecond)
+	recordCount("slow", 3, 30*time.Millisecond)
+	recordCount("middle", 2, 20*time.Millisecond)
+
+	*statsTop = 2
+	var buf bytes.Buffer
+	if err := WriteStats(&buf); err != nil {
+		t.Fatal(err)
+	}
+	_, list, ok := strings.Cut(buf.String(), "  slowest:\n")
+	if !ok {
+		t.Fatalf("got\n%s\nwant a list of the slowest packages", buf.String())
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(list), "\n") {
+		got = append(got, strings.Join(strings.Fields(line), " "))
+	}
+	want := []string{"slow 30ms 3 files", "middle 20ms 2 files"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got slowest %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	stdin         = countFlags.Bool("stdin", false, "count a single Go file read from standard input instead of loading packages")
+	stdinFilename = countFlags.String("stdin-filename", "<stdin>", "file `name` to report for -stdin")
+)
+
+// ReadFilePackage parses a single Go file from r
+// and type checks it as well as possible without its package or module.
+//
+// Type errors, such as from imports that cannot be found, are recorded
+// in TypeErrors but otherwise ignored,
+// so literals of types that could not be resolved are not counted.
+func ReadFilePackage(r io.Reader, name string) (*packages.Package, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Implicits:  map[ast.Node]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+		Scopes:     map[ast.Node]*types.Scope{},
+	}
+	var typeErrors []types.Error
+	cfg := &types.Config{
+		Importer: importer.Default(),
+		Error: func(err error) {
+			if te, ok := err.(types.Error); ok {
+				typeErrors = append(typeErrors, te)
+			}
+		},
+	}
+	// with an Error func this returns the first error after checking everything it can
+	pkg, _ := cfg.Check(f.Name.Name, fset, []*ast.File{f}, info)
+
+	return &packages.Package{
+		ID:              name,
+		Name:            f.Name.Name,
+		PkgPath:         f.Name.Name,
+		GoFiles:         []string{name},
+		CompiledGoFiles: []string{name},
+		Fset:            fset,
+		Syntax:          []*ast.File{f},
+		Types:           pkg,
+		TypesInfo:       info,
+		TypeErrors:      typeErrors,
+	}, nil
+}
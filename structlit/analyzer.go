@@ -0,0 +1,91 @@
+package structlit
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Analyzer reports every exact match, such as Name: Name,
+// where a shorthand would apply, and partial matches, such as Name: name,
+// where the value is a local variable that can be renamed to the key,
+// with a suggested fix that renames it everywhere, as preview -fix-names would,
+// so the value of every literal is unchanged.
+var Analyzer = &analysis.Analyzer{
+	Name: "structlit",
+	Doc:  "report keyed struct literal values that match their key, where a shorthand would apply, and local variables that only differ in case from their key and could be renamed to it",
+	Run:  run,
+}
+
+var reportExact, reportPartial bool
+
+func init() {
+	Analyzer.Flags.BoolVar(&reportExact, "exact", true, "report exact matches")
+	Analyzer.Flags.BoolVar(&reportPartial, "partial", true, "report partial matches whose value is a local variable that could be renamed to the key")
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	matcher := FoldMatcher(strings.EqualFold)
+	renames := Renames(pass.Pkg, pass.TypesInfo, pass.Files, matcher, nil)
+	// the fix renames the variable everywhere so only one pair carries it
+	fixed := map[*types.Var]bool{}
+	for _, f := range pass.Files {
+		StructLits(pass.TypesInfo, f, func(_ []ast.Node, _ *ast.CompositeLit, _ types.Type, kvs []*ast.KeyValueExpr) {
+			for _, kv := range kvs {
+				m := MatchOf(kv, matcher, pass.TypesInfo)
+				if m == nil {
+					continue
+				}
+				if m.Identical {
+					if !reportExact {
+						continue
+					}
+					pass.Report(analysis.Diagnostic{
+						Pos:      kv.Pos(),
+						End:      kv.End(),
+						Category: "structlit-match",
+						Message:  fmt.Sprintf("the value %s matches the key %s, so a shorthand would apply", types.ExprString(kv.Value), m.Key),
+					})
+					continue
+				}
+				if !m.Partial || !reportPartial {
+					continue
+				}
+				id := ValueIdent(kv.Value)
+				v, _ := pass.TypesInfo.Uses[id].(*types.Var)
+				r, ok := renames[v]
+				if !ok {
+					continue
+				}
+				d := analysis.Diagnostic{
+					Pos:      id.Pos(),
+					End:      id.End(),
+					Category: "structlit-partial",
+					Message:  fmt.Sprintf("%s: %s would match if %s were renamed %s", m.Key, id.Name, id.Name, m.Key),
+				}
+				if !fixed[v] {
+					fixed[v] = true
+					d.SuggestedFixes = []analysis.SuggestedFix{renameFix(id.Name, r)}
+				}
+				pass.Report(d)
+			}
+		})
+	}
+	return nil, nil
+}
+
+// renameFix returns the fix that renames the variable named old by r.
+func renameFix(old string, r Rename) analysis.SuggestedFix {
+	fix := analysis.SuggestedFix{Message: fmt.Sprintf("rename %s to %s", old, r.Name)}
+	for _, id := range r.Idents {
+		fix.TextEdits = append(fix.TextEdits, analysis.TextEdit{
+			Pos:     id.Pos(),
+			End:     id.End(),
+			NewText: []byte(r.Name),
+		})
+	}
+	return fix
+}
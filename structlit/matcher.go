@@ -0,0 +1,109 @@
+package structlit
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A Category is how the value of a pair relates to its key.
+// They are named the same as the results of Match.Result.
+type Category string
+
+const (
+	None    Category = "none"
+	Partial Category = "partial"
+	Exact   Category = "exact"
+)
+
+// A Matcher is the semantics of when the value of a pair matches its key.
+// The info is for the package the pair is in.
+type Matcher interface {
+	Match(key string, value ast.Expr, info *types.Info) Category
+}
+
+// valueName returns the name in value, as in name, *name, &name, x.name, *x.name, or &x.name,
+// and whether it is selected from x.
+func valueName(value ast.Expr) (id *ast.Ident, selector bool) {
+	switch v := value.(type) {
+	case *ast.StarExpr:
+		value = v.X
+	case *ast.UnaryExpr:
+		if v.Op == token.AND {
+			value = v.X
+		}
+	}
+	return GetIdentFrom(value)
+}
+
+// FoldMatcher is the Matcher of MatchOf's usual classification:
+// an exact match when the name in the value is the key
+// and a partial match when they are equal under the folding,
+// except for x.name, since renaming a field is not the same fix.
+type FoldMatcher func(key, name string) bool
+
+func (f FoldMatcher) Match(key string, value ast.Expr, _ *types.Info) Category {
+	id, selector := valueName(value)
+	switch {
+	case id == nil:
+		return None
+	case id.Name == key:
+		return Exact
+	case !selector && f(key, id.Name):
+		return Partial
+	}
+	return None
+}
+
+// RuleFunc is a Matcher that compares the key to the name in the value,
+// as in name, *name, &name, x.name, *x.name, or &x.name,
+// and is an exact match when it returns true.
+// Values without a name are not a match.
+type RuleFunc func(key, name string) bool
+
+func (f RuleFunc) Match(key string, value ast.Expr, _ *types.Info) Category {
+	id, _ := valueName(value)
+	if id != nil && f(key, id.Name) {
+		return Exact
+	}
+	return None
+}
+
+// RuleNames are the matching rules in the order they are reported.
+var RuleNames []string
+
+// Rules are the alternative matching semantics by name.
+var Rules = map[string]Matcher{}
+
+// RegisterRule adds a rule, which the tool's -rule can name
+// when the package registering it is built into it.
+func RegisterRule(name string, m Matcher) {
+	RuleNames = append(RuleNames, name)
+	Rules[name] = m
+}
+
+func init() {
+	// Name: Name
+	RegisterRule("exact", RuleFunc(func(key, name string) bool {
+		return key == name
+	}))
+	// Name: NAME
+	RegisterRule("fold", RuleFunc(strings.EqualFold))
+	// Name: Name exact and Name: name partial, as counted by default
+	RegisterRule("default", FoldMatcher(strings.EqualFold))
+	// Name: nameOrDefault
+	RegisterRule("prefix", RuleFunc(func(key, name string) bool {
+		return strings.HasPrefix(strings.ToLower(name), strings.ToLower(key))
+	}))
+	// Name: name but not Name: nAME
+	RegisterRule("unexported-fold", RuleFunc(func(key, name string) bool {
+		if key == name {
+			return true
+		}
+		r, n := utf8.DecodeRuneInString(key)
+		return unicode.IsUpper(r) && name == string(unicode.ToLower(r))+key[n:]
+	}))
+}
@@ -0,0 +1,46 @@
+package structlit
+
+import (
+	"github.com/golangci/plugin-module-register/register"
+	"golang.org/x/tools/go/analysis"
+)
+
+func init() {
+	register.Plugin("structlit", New)
+}
+
+// Settings are the settings of the golangci-lint plugin.
+// An unset setting keeps the default of the analyzer flag of the same name.
+type Settings struct {
+	Exact   *bool `json:"exact"`
+	Partial *bool `json:"partial"`
+}
+
+// New returns the golangci-lint plugin of Analyzer configured by settings,
+// from the golangci-lint configuration.
+func New(settings any) (register.LinterPlugin, error) {
+	s, err := register.DecodeSettings[Settings](settings)
+	if err != nil {
+		return nil, err
+	}
+	return plugin{s}, nil
+}
+
+type plugin struct {
+	settings Settings
+}
+
+func (p plugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
+	if p.settings.Exact != nil {
+		reportExact = *p.settings.Exact
+	}
+	if p.settings.Partial != nil {
+		reportPartial = *p.settings.Partial
+	}
+	return []*analysis.Analyzer{Analyzer}, nil
+}
+
+// GetLoadMode is types info since the matches need the types of the literals.
+func (plugin) GetLoadMode() string {
+	return register.LoadModeTypesInfo
+}
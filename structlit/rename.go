@@ -0,0 +1,99 @@
+package structlit
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// A Rename is the new name of a variable and every identifier
+// that declares or uses it.
+type Rename struct {
+	Name   string
+	Idents []*ast.Ident
+}
+
+// Renames returns the renames of the local variables of pkg
+// that are the values of pairs in files, of literals keep reports true for,
+// that are partial matches under m, such as those that only differ in case from their key.
+// The renamed variable is the same variable, so the value of every literal is unchanged.
+//
+// A variable is only renamed when every such pair agrees on the name
+// and nothing named the new name is in scope where it is declared or used.
+func Renames(pkg *types.Package, info *types.Info, files []*ast.File, m Matcher, keep func(f *ast.File, c *ast.CompositeLit, typ types.Type) bool) map[*types.Var]Rename {
+	want := map[*types.Var]string{}
+	disagree := map[*types.Var]bool{}
+	for _, f := range files {
+		StructLits(info, f, func(_ []ast.Node, c *ast.CompositeLit, typ types.Type, kvs []*ast.KeyValueExpr) {
+			if keep != nil && !keep(f, c, typ) {
+				return
+			}
+			for _, kv := range kvs {
+				match := MatchOf(kv, m, info)
+				if match == nil || !match.Partial {
+					continue
+				}
+				id := ValueIdent(kv.Value)
+				v, ok := info.Uses[id].(*types.Var)
+				// case folding may change the length
+				if !ok || !isLocal(pkg, v) || len(id.Name) != len(match.Key) {
+					continue
+				}
+				if name, ok := want[v]; ok && name != match.Key {
+					disagree[v] = true
+				}
+				want[v] = match.Key
+			}
+		})
+	}
+	if len(want) == 0 {
+		return nil
+	}
+
+	// the variable of each case of a type switch is implicit
+	// and renaming one would need all of them renamed
+	implicit := map[types.Object]bool{}
+	for _, o := range info.Implicits {
+		implicit[o] = true
+	}
+	idents := map[*types.Var][]*ast.Ident{}
+	for _, m := range []map[*ast.Ident]types.Object{info.Defs, info.Uses} {
+		for id, o := range m {
+			if v, ok := o.(*types.Var); ok && want[v] != "" {
+				idents[v] = append(idents[v], id)
+			}
+		}
+	}
+
+	renames := map[*types.Var]Rename{}
+	for v, name := range want {
+		if disagree[v] || implicit[v] || !renamable(pkg, idents[v], name) {
+			continue
+		}
+		ids := idents[v]
+		sort.Slice(ids, func(i, j int) bool { return ids[i].Pos() < ids[j].Pos() })
+		renames[v] = Rename{name, ids}
+	}
+	return renames
+}
+
+// isLocal reports whether v is a variable declared in a function of pkg.
+func isLocal(pkg *types.Package, v *types.Var) bool {
+	return !v.IsField() && v.Pkg() == pkg && v.Parent() != nil && v.Parent() != pkg.Scope()
+}
+
+// renamable reports whether nothing named name is in scope
+// at any of the identifiers, whether declared before or after.
+func renamable(pkg *types.Package, ids []*ast.Ident, name string) bool {
+	for _, id := range ids {
+		s := pkg.Scope().Innermost(id.Pos())
+		if s == nil {
+			return false
+		}
+		if _, o := s.LookupParent(name, token.NoPos); o != nil {
+			return false
+		}
+	}
+	return len(ids) > 0
+}
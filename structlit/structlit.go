@@ -0,0 +1,228 @@
+// Package structlit finds the pairs of keyed struct literals
+// whose value matches their key, as in Name: Name,
+// where a shorthand would apply.
+//
+// Its Analyzer reports them and registers as a golangci-lint module plugin.
+package structlit
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// StructLits calls fn with each keyed literal of a struct type in f,
+// the nodes enclosing it from the file down, its type, and its KV pairs.
+// Literals that could not be type checked are skipped.
+func StructLits(info *types.Info, f *ast.File, fn func(path []ast.Node, c *ast.CompositeLit, typ types.Type, kvs []*ast.KeyValueExpr)) {
+	var path []ast.Node
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			path = path[:len(path)-1]
+			return false
+		}
+		defer func() {
+			path = append(path, n)
+		}()
+		c, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		// only care if composite lit of a struct type
+		typ := LitType(info, c)
+		if typ == nil {
+			return true
+		}
+		var kvs []*ast.KeyValueExpr
+		for _, x := range c.Elts {
+			// only care if keyed
+			if kv, ok := x.(*ast.KeyValueExpr); ok {
+				kvs = append(kvs, kv)
+			}
+		}
+		if len(kvs) > 0 {
+			fn(path, c, typ, kvs)
+		}
+		return true
+	})
+}
+
+// LitType returns the type of c if it is a struct type and otherwise nil,
+// including when c could not be type checked and its type cannot be resolved
+// by its name, as with a type error in the literal itself.
+// This includes instantiated generic types, such as Pair[int],
+// and type parameters constrained to a single struct type.
+func LitType(info *types.Info, c *ast.CompositeLit) types.Type {
+	typ := info.Types[c].Type
+	if typ == nil || typ == types.Typ[types.Invalid] {
+		typ = namedType(info, c.Type)
+	}
+	if typ == nil {
+		return nil
+	}
+	// an elided &T, as in []*T{{A: a}}, is recorded as *T
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok && c.Type == nil {
+		typ = ptr.Elem()
+	}
+	if StructOf(typ) == nil {
+		return nil
+	}
+	return typ
+}
+
+// namedType returns the type named by x, as in T{} or pkg.T{},
+// or nil if x is anything else or does not resolve.
+func namedType(info *types.Info, x ast.Expr) types.Type {
+	var id *ast.Ident
+	switch x := x.(type) {
+	case *ast.Ident:
+		id = x
+	case *ast.SelectorExpr:
+		id = x.Sel
+	default:
+		return nil
+	}
+	if tn, ok := info.Uses[id].(*types.TypeName); ok {
+		return tn.Type()
+	}
+	return nil
+}
+
+// StructOf returns the struct that typ is or nil.
+// For a type parameter this is the struct type of its constraint,
+// as in P ~struct{ X int }, since that is what a literal of P has.
+func StructOf(typ types.Type) *types.Struct {
+	if tp, ok := typ.(*types.TypeParam); ok {
+		iface, ok := tp.Constraint().Underlying().(*types.Interface)
+		if !ok || iface.NumEmbeddeds() != 1 {
+			return nil
+		}
+		typ = iface.EmbeddedType(0)
+		if u, ok := typ.(*types.Union); ok {
+			if u.Len() != 1 {
+				return nil
+			}
+			typ = u.Term(0).Type()
+		}
+	}
+	s, _ := typ.Underlying().(*types.Struct)
+	return s
+}
+
+type Match struct {
+	Key                          string
+	Identical, Partial           bool
+	Regular, Star, Amp, Selector bool
+	// Package is not set by MatchOf but by callers that know
+	// the selector is qualified by an imported package, as in time.Second.
+	Package bool
+	// Name is the name in the value, as in name, x.name, *name, or &name.
+	Name string
+}
+
+// MatchOf returns the match of the key and value of kv,
+// exact or partial as m classifies it, with info for the package of kv,
+// or nil if the value is not a name, x.name, *name, or &name.
+func MatchOf(kv *ast.KeyValueExpr, m Matcher, info *types.Info) *Match {
+	var Star, Amp bool
+	ident, Selector := GetIdentFrom(kv.Value)
+
+	// if these fire ident was nil anyway
+	switch v := kv.Value.(type) {
+	case *ast.StarExpr:
+		// only count *name
+		ident, Selector = GetIdentFrom(v.X)
+		Star = true
+	case *ast.UnaryExpr:
+		// only count &name
+		if v.Op == token.AND {
+			ident, Selector = GetIdentFrom(v.X)
+			Amp = true
+		}
+	}
+	// a key that is not a name is from a malformed AST
+	k, ok := kv.Key.(*ast.Ident)
+	if ident == nil || !ok {
+		return nil
+	}
+
+	key := k.Name
+	name := ident.Name
+
+	category := m.Match(key, kv.Value, info)
+	Identical := category == Exact
+	partial := category == Partial
+
+	return &Match{
+		Key:     key,
+		Name:    name,
+		Regular: !Star && !Amp && !Selector,
+		// Partial is a partial match so we have one for testing
+		Partial: partial,
+		// These all count as simple idents with exact matches
+		Identical: Identical,
+		Star:      Star,
+		Amp:       Amp,
+		Selector:  Selector,
+	}
+}
+
+// Kind returns the name of the tally m is counted in,
+// or "not_ident" for a nil m.
+func (m *Match) Kind() string {
+	switch {
+	case m == nil:
+		return "not_ident"
+	case m.Regular:
+		return "ident"
+	case m.Star && m.Selector:
+		return "qualified_star"
+	case m.Star:
+		return "star"
+	case m.Amp && m.Selector:
+		return "qualified_amp"
+	case m.Amp:
+		return "amp"
+	}
+	return "qualified_ident"
+}
+
+// Result returns "exact", "partial", or "none".
+func (m *Match) Result() string {
+	switch {
+	case m == nil:
+		return "none"
+	case m.Identical:
+		return "exact"
+	case m.Partial:
+		return "partial"
+	}
+	return "none"
+}
+
+func GetIdentFrom(n ast.Node) (ident *ast.Ident, selector bool) {
+	switch v := n.(type) {
+	case *ast.Ident:
+		ident = v
+	case *ast.SelectorExpr:
+		// only count name.name
+		if _, ok := v.X.(*ast.Ident); ok {
+			ident, selector = v.Sel, true
+		}
+	}
+	return ident, selector
+}
+
+// ValueIdent returns the identifier in name, *name, or &name.
+func ValueIdent(x ast.Expr) *ast.Ident {
+	switch v := x.(type) {
+	case *ast.StarExpr:
+		x = v.X
+	case *ast.UnaryExpr:
+		if v.Op == token.AND {
+			x = v.X
+		}
+	}
+	id, _ := x.(*ast.Ident)
+	return id
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/jimmyfrasche/issue57949/structlit"
+	"golang.org/x/tools/go/packages"
+)
+
+var countTypes = countFlags.Bool("types", false, "also count the keyed and positional literals of each struct type")
+
+// TypeCount is the literals of one struct type.
+// Empty literals are neither keyed nor positional.
+type TypeCount struct {
+	Keyed      uint64 `json:"keyed"`
+	Positional uint64 `json:"positional"`
+}
+
+// CountTypes adds the literals of each struct type in f to c.Types.
+func CountTypes(c *Count, p *packages.Package, f *ast.File) {
+	skipped := Skipped(f)
+	ast.Inspect(f, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok || len(lit.Elts) == 0 || skipped(lit.Pos()) {
+			return true
+		}
+		typ := structlit.LitType(p.TypesInfo, lit)
+		if typ == nil || !CountedType(typ) {
+			return true
+		}
+		if c.Types == nil {
+			c.Types = map[string]*TypeCount{}
+		}
+		tc := c.Types[typ.String()]
+		if tc == nil {
+			tc = &TypeCount{}
+			c.Types[typ.String()] = tc
+		}
+		// a literal is all keyed or all positional
+		if _, ok := lit.Elts[0].(*ast.KeyValueExpr); ok {
+			tc.Keyed++
+		} else {
+			tc.Positional++
+		}
+		return true
+	})
+}
+
+// addTypes adds the type counts of o to c.
+func (c *Count) addTypes(o *Count) {
+	for name, tc := range o.Types {
+		if c.Types == nil {
+			c.Types = map[string]*TypeCount{}
+		}
+		if c.Types[name] == nil {
+			c.Types[name] = &TypeCount{}
+		}
+		c.Types[name].Keyed += tc.Keyed
+		c.Types[name].Positional += tc.Positional
+	}
+}
+
+// writeTypes writes a table of c.Types sorted by type.
+func (c *Count) writeTypes(w io.Writer) {
+	if len(c.Types) == 0 {
+		return
+	}
+	var names []string
+	for name := range c.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "struct type\tkeyed\tpositional")
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", name, c.Types[name].Keyed, c.Types[name].Positional)
+	}
+	tw.Flush()
+}
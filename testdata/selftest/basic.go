@@ -0,0 +1,56 @@
+// Each pair is on its own line with the tally it is counted in
+// and its result, as selftest expects.
+package selftest
+
+import "time"
+
+type T struct {
+	Name    string
+	Title   string
+	Ärger   string
+	Addr    *string
+	Next    *T
+	Timeout time.Duration
+	N       int
+}
+
+type config struct {
+	Name    string
+	Title   *string
+	Timeout time.Duration
+}
+
+var cfg config
+
+func literals(Name, title, nAME, ärger string, addr *string, Title *string, next T) []T {
+	Timeout := time.Second
+	return []T{
+		{
+			Name:    Name,    // want ident exact
+			Title:   title,   // want ident partial
+			Ärger:   ärger,   // want ident partial
+			Addr:    addr,    // want ident partial
+			Timeout: Timeout, // want ident exact
+			N:       1,       // want not_ident none
+		},
+		{
+			Name:    nAME,        // want ident partial
+			Title:   *Title,      // want star exact
+			Next:    &next,       // want amp partial
+			Timeout: time.Second, // want qualified_ident none
+			N:       len(Name),   // want not_ident none
+		},
+		{
+			Name:    cfg.Name,    // want qualified_ident exact
+			Title:   *cfg.Title,  // want qualified_star exact
+			Timeout: cfg.Timeout, // want qualified_ident exact
+			Addr:    &cfg.Name,   // want qualified_amp none
+		},
+	}
+}
+
+// positional and map literals are not counted
+var (
+	_ = T{"a", "b", "c", nil, nil, 0, 1}
+	_ = map[string]int{"Name": 1}
+)
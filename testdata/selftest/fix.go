@@ -0,0 +1,26 @@
+// The renames suggested for partial matches keep every value the same,
+// as selftest checks by applying them and type checking the result.
+package selftest
+
+type Server struct {
+	Name string
+	Addr string
+	Port int
+}
+
+func servers(port int) []Server {
+	addr := "localhost"
+	name, Name := "a", "b"
+	return []Server{
+		{
+			Addr: addr, // want ident partial
+			Port: port, // want ident partial
+			// Name is in scope so name cannot be renamed
+			Name: name, // want ident partial
+		},
+		{
+			Addr: addr + ":" + name, // want not_ident none
+			Name: Name,              // want ident exact
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+var topFields = countFlags.Int("top", 0, "list the `n` struct type and field pairs with the most exact matches")
+
+// countField counts an exact match of the field key of typ in c.Fields.
+func (c *Count) countField(typ, key string) {
+	if c.Fields == nil {
+		c.Fields = map[string]uint64{}
+	}
+	c.Fields[typ+"."+key]++
+}
+
+// addFields adds the fields of o to c.
+func (c *Count) addFields(o *Count) {
+	for f, n := range o.Fields {
+		if c.Fields == nil {
+			c.Fields = map[string]uint64{}
+		}
+		c.Fields[f] += n
+	}
+}
+
+// TopFields returns up to n fields of c with the most exact matches,
+// most first then by name.
+func (c *Count) TopFields(n int) []string {
+	var fs []string
+	for f := range c.Fields {
+		fs = append(fs, f)
+	}
+	sort.Slice(fs, func(i, j int) bool {
+		if c.Fields[fs[i]] != c.Fields[fs[j]] {
+			return c.Fields[fs[i]] > c.Fields[fs[j]]
+		}
+		return fs[i] < fs[j]
+	})
+	if len(fs) > n {
+		fs = fs[:n]
+	}
+	return fs
+}
+
+// writeTopFields writes the -top fields of c.
+func (c *Count) writeTopFields(w io.Writer) {
+	fs := c.TopFields(*topFields)
+	if len(fs) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "type.field\texact")
+	for _, f := range fs {
+		fmt.Fprintf(tw, "%s\t%d\n", f, c.Fields[f])
+	}
+	tw.Flush()
+}
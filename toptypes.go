@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+var (
+	topTypes    = countFlags.Int("top-types", 0, "list the `k` struct types of each package with the highest ratio of exact matches to KV pairs")
+	topTypesMin = countFlags.Int("top-types-min", 5, "list only struct types with at least `n` keyed literals with -top-types")
+)
+
+// TypeTally is the keyed literals of a struct type, their KV pairs,
+// and how many of those are exact matches.
+type TypeTally struct {
+	Literals uint64 `json:"literals"`
+	KV       uint64 `json:"kv"`
+	Exact    uint64 `json:"exact"`
+}
+
+// Ratio is the fraction of the KV pairs that are exact matches.
+func (t *TypeTally) Ratio() float64 {
+	return ratio(t.Exact, t.KV)
+}
+
+// countTypeTally counts a literal of typ with kv pairs, exact of them exact matches,
+// in c.TypeTallies.
+func (c *Count) countTypeTally(typ string, kv, exact int) {
+	if c.TypeTallies == nil {
+		c.TypeTallies = map[string]*TypeTally{}
+	}
+	t := c.TypeTallies[typ]
+	if t == nil {
+		t = &TypeTally{}
+		c.TypeTallies[typ] = t
+	}
+	t.Literals++
+	t.KV += uint64(kv)
+	t.Exact += uint64(exact)
+}
+
+// addTypeTallies adds the type tallies of o to c.
+func (c *Count) addTypeTallies(o *Count) {
+	for name, ot := range o.TypeTallies {
+		if c.TypeTallies == nil {
+			c.TypeTallies = map[string]*TypeTally{}
+		}
+		t := c.TypeTallies[name]
+		if t == nil {
+			t = &TypeTally{}
+			c.TypeTallies[name] = t
+		}
+		t.Literals += ot.Literals
+		t.KV += ot.KV
+		t.Exact += ot.Exact
+	}
+}
+
+// TopTypes returns up to k struct types of c with at least least literals
+// and the highest ratio of exact matches, highest first
+// then by the most exact matches and then by name.
+func (c *Count) TopTypes(k int, least uint64) []string {
+	var ts []string
+	for name, t := range c.TypeTallies {
+		if t.Literals >= least && t.Exact > 0 {
+			ts = append(ts, name)
+		}
+	}
+	sort.Slice(ts, func(i, j int) bool {
+		a, b := c.TypeTallies[ts[i]], c.TypeTallies[ts[j]]
+		switch {
+		case a.Ratio() != b.Ratio():
+			return a.Ratio() > b.Ratio()
+		case a.Exact != b.Exact:
+			return a.Exact > b.Exact
+		}
+		return ts[i] < ts[j]
+	})
+	if len(ts) > k {
+		ts = ts[:k]
+	}
+	return ts
+}
+
+// writeTopTypes writes the -top-types struct types of c.
+func (c *Count) writeTopTypes(w io.Writer) {
+	if *topTypes <= 0 {
+		return
+	}
+	ts := c.TopTypes(*topTypes, uint64(*topTypesMin))
+	if len(ts) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "struct type\tliterals\tKV pairs\texact\texact / KV")
+	for _, name := range ts {
+		t := c.TypeTallies[name]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.1f%%\n", name, t.Literals, t.KV, t.Exact, 100*t.Ratio())
+	}
+	tw.Flush()
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+var (
+	treemapWidth  = treemapFlags.Int("width", 1200, "the width of the treemap in pixels")
+	treemapHeight = treemapFlags.Int("height", 800, "the height of the treemap in pixels")
+)
+
+// Treemap runs the treemap command: it counts the packages
+// and writes an SVG treemap of them by directory,
+// where the area of each is its KV pairs and its color the ratio of exact matches,
+// from red for none to green for all.
+func Treemap(ctx context.Context, w io.Writer, args []string) error {
+	if *jsonOut {
+		return errors.New("treemap: -json is not supported; the output is SVG")
+	}
+	args, err := Patterns(ctx, args)
+	if err != nil {
+		return err
+	}
+	ps, skipped, err := GetPackages(ctx, "", args)
+	if err != nil {
+		return err
+	}
+	LogSkipped(skipped)
+
+	root := &treeNode{}
+	for _, p := range ps {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c := CountPackage(ctx, p)
+		root.add(strings.Split(c.ID, "/"), c)
+	}
+	root.collapse()
+	if root.kv == 0 {
+		return errors.New("treemap: no KV pairs")
+	}
+
+	width, height := float64(*treemapWidth), float64(*treemapHeight)
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="11">`+"\n", *treemapWidth, *treemapHeight)
+	root.write(w, "", 0, 0, width, height)
+	_, err = fmt.Fprintln(w, "</svg>")
+	return err
+}
+
+// treeNode is a directory or package of the treemap.
+type treeNode struct {
+	name      string
+	kv, exact uint64
+	// pkg is set if a package is at this path
+	// and its pairs are in kv but not the children.
+	pkg      bool
+	children map[string]*treeNode
+}
+
+func (n *treeNode) add(path []string, c *Count) {
+	exact := uint64(c.Metrics()["exact"])
+	n.kv += c.KV
+	n.exact += exact
+	if len(path) == 0 {
+		n.pkg = true
+		return
+	}
+	if n.children == nil {
+		n.children = map[string]*treeNode{}
+	}
+	child, ok := n.children[path[0]]
+	if !ok {
+		child = &treeNode{name: path[0]}
+		n.children[path[0]] = child
+	}
+	child.add(path[1:], c)
+}
+
+// collapse joins each directory with its only child, as in github.com/x/y,
+// so long paths do not nest for nothing.
+func (n *treeNode) collapse() {
+	for _, c := range n.children {
+		c.collapse()
+	}
+	if len(n.children) != 1 || n.pkg {
+		return
+	}
+	for _, c := range n.children {
+		if n.name != "" {
+			c.name = n.name + "/" + c.name
+		}
+		*n = *c
+	}
+}
+
+// sorted returns the children with any pairs, most first then by name,
+// with the pairs of a package at n itself as the first child named ".".
+func (n *treeNode) sorted() []*treeNode {
+	var cs []*treeNode
+	own := n.kv
+	for _, c := range n.children {
+		own -= c.kv
+		if c.kv > 0 {
+			cs = append(cs, c)
+		}
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].kv != cs[j].kv {
+			return cs[i].kv > cs[j].kv
+		}
+		return cs[i].name < cs[j].name
+	})
+	if n.pkg && own > 0 && len(cs) > 0 {
+		exact := n.exact
+		for _, c := range cs {
+			exact -= c.exact
+		}
+		cs = append([]*treeNode{{name: ".", kv: own, exact: exact, pkg: true}}, cs...)
+	}
+	return cs
+}
+
+// write writes n, whose path is prefix, in the rectangle at x, y of size w by h.
+// The children of a directory are laid out by squarify.
+func (n *treeNode) write(out io.Writer, prefix string, x, y, w, h float64) {
+	path := n.name
+	if prefix != "" {
+		path = prefix + "/" + n.name
+	}
+	ratio := float64(n.exact) / float64(n.kv)
+	title := html.EscapeString(fmt.Sprintf("%s: %d KV pairs, %d exact (%.1f%%)", path, n.kv, n.exact, 100*ratio))
+
+	cs := n.sorted()
+	if len(cs) == 0 {
+		fmt.Fprintf(out, `<g><title>%s</title><rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="hsl(%.0f,60%%,50%%)" stroke="#fff"/>`,
+			title, x, y, w, h, 120*ratio)
+		if w > 40 && h > 14 {
+			fmt.Fprintf(out, `<text x="%.1f" y="%.1f" fill="#fff">%s</text>`, x+3, y+12, html.EscapeString(n.name))
+		}
+		fmt.Fprintln(out, "</g>")
+		return
+	}
+
+	fmt.Fprintf(out, `<g><title>%s</title><rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="#333" stroke="#fff"/></g>`+"\n", title, x, y, w, h)
+	// room for the name of the directory, if there is space
+	if n.name != "" && h > 40 && w > 40 {
+		fmt.Fprintf(out, `<text x="%.1f" y="%.1f" fill="#fff">%s</text>`+"\n", x+3, y+11, html.EscapeString(n.name))
+		y, h = y+14, h-14
+	}
+	x, y, w, h = x+1, y+1, w-2, h-2
+	areas := make([]float64, len(cs))
+	for i, c := range cs {
+		areas[i] = w * h * float64(c.kv) / float64(n.kv)
+	}
+	for i, r := range squarify(areas, rect{x, y, w, h}) {
+		cs[i].write(out, path, r.x, r.y, r.w, r.h)
+	}
+}
+
+type rect struct{ x, y, w, h float64 }
+
+// squarify lays out areas, largest first, in r so that
+// the rectangles are as close to squares as it can manage,
+// as in Bruls, Huizing, and van Wijk, "Squarified Treemaps".
+// Each row is filled along the shorter side of what is left of r
+// for as long as adding to it makes its worst aspect ratio better.
+func squarify(areas []float64, r rect) []rect {
+	var out []rect
+	for len(areas) > 0 {
+		side := r.w
+		if r.h < side {
+			side = r.h
+		}
+		n := 1
+		for n < len(areas) && worst(areas[:n+1], side) <= worst(areas[:n], side) {
+			n++
+		}
+		row := areas[:n]
+		areas = areas[n:]
+
+		sum := 0.0
+		for _, a := range row {
+			sum += a
+		}
+		if side <= 0 || sum <= 0 {
+			for range row {
+				out = append(out, rect{r.x, r.y, 0, 0})
+			}
+			continue
+		}
+		// the thickness of the row
+		t := sum / side
+		x, y := r.x, r.y
+		for _, a := range row {
+			if r.w >= r.h {
+				// a column at the left
+				out = append(out, rect{x, y, t, a / t})
+				y += a / t
+			} else {
+				// a row at the top
+				out = append(out, rect{x, y, a / t, t})
+				x += a / t
+			}
+		}
+		if r.w >= r.h {
+			r.x, r.w = r.x+t, r.w-t
+		} else {
+			r.y, r.h = r.y+t, r.h-t
+		}
+	}
+	return out
+}
+
+// worst is the worst aspect ratio of the row of areas along side.
+func worst(row []float64, side float64) float64 {
+	sum, lo, hi := 0.0, row[0], row[0]
+	for _, a := range row {
+		sum += a
+		if a < lo {
+			lo = a
+		}
+		if a > hi {
+			hi = a
+		}
+	}
+	if lo <= 0 {
+		return math.Inf(1)
+	}
+	s2, side2 := sum*sum, side*side
+	r := side2 * hi / s2
+	if q := s2 / (side2 * lo); q > r {
+		r = q
+	}
+	return r
+}
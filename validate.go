@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// A flagRule is checked against the flags given on the command line
+// or from the config file.
+// Each flag is a flag name, which holds when it is given (and true for a boolean),
+// or name=value, which holds when it is given that value.
+type flagRule struct {
+	// if every flag in all holds
+	all []string
+	// then the flag in need must as well, or, if need is "", it is an error
+	need string
+	// why is what to do instead
+	why string
+}
+
+var flagRules = []flagRule{
+	{all: []string{"json", "stream"}, why: "-stream writes each package as text as it is counted; drop -stream for a JSON report"},
+	{all: []string{"stream", "watch"}, why: "-watch writes a new report after each change; drop -stream"},
+	{all: []string{"out=gh-annotations", "json"}, why: "annotations replace the report; drop -json"},
+	{all: []string{"out=gh-annotations", "stream"}, why: "annotations replace the report; drop -stream"},
+	{all: []string{"out=gh-annotations", "watch"}, why: "annotations are written once for the run; drop -watch"},
+	{all: []string{"q", "json"}, why: "-q writes tab-separated lines; drop one"},
+	{all: []string{"q", "out=gh-annotations"}, why: "annotations replace the report; drop -q"},
+	{all: []string{"out=document", "json"}, why: "the document is always JSON; drop -json"},
+	{all: []string{"out=document", "stream"}, why: "the document is written once every package is counted; drop -stream"},
+	{all: []string{"out=document", "watch"}, why: "the document is written once for the run; drop -watch"},
+	{all: []string{"out=document", "q"}, why: "the document replaces the report; drop -q"},
+	{all: []string{"out=document", "sites=jsonl"}, why: "the document has the sites; drop -sites"},
+	{all: []string{"out=document", "sites=csv"}, why: "the document has the sites; drop -sites"},
+	{all: []string{"sites=jsonl", "json"}, why: "the site records replace the report; drop -json"},
+	{all: []string{"sites=jsonl", "stream"}, why: "the site records replace the report; drop -stream"},
+	{all: []string{"sites=jsonl", "watch"}, why: "the site records are written once for the run; drop -watch"},
+	{all: []string{"sites=jsonl", "q"}, why: "the site records replace the report; drop -q"},
+	{all: []string{"sites=jsonl", "out=gh-annotations"}, why: "both replace the report; choose one"},
+	{all: []string{"sites=csv", "json"}, why: "the site records replace the report; drop -json"},
+	{all: []string{"sites=csv", "stream"}, why: "the site records replace the report; drop -stream"},
+	{all: []string{"sites=csv", "watch"}, why: "the site records are written once for the run; drop -watch"},
+	{all: []string{"sites=csv", "q"}, why: "the site records replace the report; drop -q"},
+	{all: []string{"no-total", "total-always"}, why: "choose one"},
+	{all: []string{"no-total", "json"}, why: "the JSON report always has the total; drop -no-total"},
+	{all: []string{"totals-only", "no-total"}, why: "choose one"},
+	{all: []string{"totals-only", "stream"}, why: "-stream writes each package as it is counted; drop -stream"},
+	{all: []string{"totals-only", "out=document"}, why: "the document has every package; drop -totals-only"},
+	{all: []string{"max-memory", "watch"}, why: "-watch keeps every package loaded to recount it; drop -max-memory"},
+	{all: []string{"max-memory", "stdin"}, why: "-stdin counts a single file; drop -max-memory"},
+	{all: []string{"sites=csv", "out=gh-annotations"}, why: "both replace the report; choose one"},
+	{all: []string{"baseline", "q"}, why: "-q lines have a fixed set of columns; drop -baseline"},
+	{all: []string{"detail", "q"}, why: "-q lines have a fixed set of columns; drop -detail"},
+	{all: []string{"same-path=merge", "stream"}, why: "packages are merged after all are counted; drop -stream"},
+	{all: []string{"keep-going", "errors=fail"}, why: "-keep-going is -errors=report"},
+	{all: []string{"keep-going", "errors=skip"}, why: "-keep-going is -errors=report"},
+	{all: []string{"stdin", "watch"}, why: "standard input cannot be watched for changes"},
+	{all: []string{"stdin", "workspace"}, why: "-stdin counts a single file, not modules"},
+	{all: []string{"stdin", "patterns"}, why: "-stdin counts a single file, not packages"},
+	{all: []string{"stdin", "test"}, why: "-stdin counts a single file, not packages"},
+	{all: []string{"stdin-filename"}, need: "stdin", why: "it names the file read with -stdin"},
+	{all: []string{"watch-interval"}, need: "watch"},
+	{all: []string{"debug-addr"}, need: "watch"},
+	{all: []string{"cache-dir"}, need: "cache"},
+	{all: []string{"stats-top"}, need: "stats"},
+	{all: []string{"w"}, need: "fix-names", why: "without the shorthand in the language, code with the keys elided does not compile"},
+}
+
+// ValidateFlags returns an error describing every combination of the flags set in fs
+// that cannot be used together, rather than letting one silently win.
+// Rules about flags that are not in fs are ignored.
+func ValidateFlags(fs *flag.FlagSet) error {
+	set := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = f.Value.String()
+	})
+	// holds reports whether the flag of spec is in fs and, if so, whether it holds.
+	holds := func(spec string) (known, ok bool) {
+		name, value, hasValue := strings.Cut(spec, "=")
+		f := fs.Lookup(name)
+		if f == nil {
+			return false, false
+		}
+		v, given := set[name]
+		if !given {
+			return true, false
+		}
+		if hasValue {
+			return true, v == value
+		}
+		if b, isBool := f.Value.(interface{ IsBoolFlag() bool }); isBool && b.IsBoolFlag() {
+			return true, v != "false"
+		}
+		return true, true
+	}
+
+	var errs []error
+rules:
+	for _, r := range flagRules {
+		for _, spec := range r.all {
+			known, ok := holds(spec)
+			if !known || !ok {
+				continue rules
+			}
+		}
+		var msg string
+		if r.need == "" {
+			msg = fmt.Sprintf("%s cannot be used together", flagList(r.all))
+		} else {
+			if known, ok := holds(r.need); !known || ok {
+				continue
+			}
+			msg = fmt.Sprintf("%s needs -%s", flagList(r.all), r.need)
+		}
+		if r.why != "" {
+			msg += ": " + r.why
+		}
+		errs = append(errs, errors.New(msg))
+	}
+	return errors.Join(errs...)
+}
+
+// flagList is the specs in the form they are written on the command line.
+func flagList(specs []string) string {
+	var s []string
+	for _, spec := range specs {
+		s = append(s, "-"+spec)
+	}
+	if len(s) == 2 {
+		return s[0] + " and " + s[1]
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	watch         = countFlags.Bool("watch", false, "after the report, watch the packages for changes and print a new report after each")
+	watchInterval = countFlags.Duration("watch-interval", time.Second, "how often -watch checks for changes")
+)
+
+// Watch polls the files and directories of ps for changes
+// and, after any, counts the affected packages again,
+// or every package with -duplicates or -dedupe,
+// and writes a new report, until ctx is done.
+// counts are the current counts of all packages including ps.
+func Watch(ctx context.Context, w io.Writer, ps []*packages.Package, counts []*Count) error {
+	byID := map[string]*Count{}
+	for _, c := range counts {
+		byID[c.ID] = c
+	}
+
+	// the modification time of each file and directory of each watched package
+	stamps := map[string]map[string]time.Time{}
+	paths := map[string]string{}
+	stamp := func(p *packages.Package) {
+		m := map[string]time.Time{}
+		for _, f := range p.GoFiles {
+			m[f] = modTime(f)
+			// catch added or removed files
+			d := filepath.Dir(f)
+			m[d] = modTime(d)
+		}
+		stamps[p.ID] = m
+		paths[p.ID] = p.PkgPath
+	}
+	for _, p := range ps {
+		stamp(p)
+	}
+
+	tick := time.NewTicker(*watchInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// interrupting is how watching ends
+			return nil
+		case <-tick.C:
+		}
+
+		var ids, changed []string
+		for id, m := range stamps {
+			for name, t := range m {
+				if !modTime(name).Equal(t) {
+					ids = append(ids, id)
+					changed = append(changed, paths[id])
+					break
+				}
+			}
+		}
+		if len(changed) == 0 {
+			continue
+		}
+		sort.Strings(changed)
+		load := changed
+		if *findDuplicates || *dedupe {
+			// which of the same literals is first depends on every package
+			ids, load = nil, nil
+			for id := range stamps {
+				ids = append(ids, id)
+				load = append(load, paths[id])
+			}
+			sort.Strings(load)
+		}
+		ForgetLiterals()
+		ForgetOwners(ids)
+
+		ps, skipped, err := GetPackages(ctx, "", load)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// likely a file saved mid-edit so wait for the next change
+			var le *LoadError
+			if errors.As(err, &le) {
+				le.Write(os.Stderr)
+			}
+			log.Println(err)
+			for _, id := range ids {
+				for name := range stamps[id] {
+					stamps[id][name] = modTime(name)
+				}
+			}
+			continue
+		}
+		LogSkipped(skipped)
+		for _, p := range ps {
+			stamp(p)
+			byID[p.ID] = CountPackage(ctx, p)
+		}
+
+		r := &Report{Total: New("<total>")}
+		for _, c := range byID {
+			r.Packages = append(r.Packages, c)
+			r.Total.Add(c)
+		}
+		SortCounts(r.Packages)
+		if !*quiet {
+			fmt.Fprintf(w, "--- %s: changed %v\n", time.Now().Format(time.TimeOnly), changed)
+		}
+		if err := r.Write(w); err != nil {
+			return err
+		}
+	}
+}
+
+// modTime returns the modification time of name
+// or the zero time if it does not exist.
+func modTime(name string) time.Time {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var workspace = loadFlags.Bool("workspace", false, "count every module in the go.work workspace, with a subtotal per module")
+
+// WorkspacePatterns returns a pattern matching every package
+// in each module used by the go.work file in effect for dir.
+func WorkspacePatterns(ctx context.Context, dir string) ([]string, error) {
+	gowork, err := goCmd(ctx, dir, "env", "GOWORK")
+	if err != nil {
+		return nil, err
+	}
+	gowork = strings.TrimSpace(gowork)
+	if gowork == "" || gowork == "off" {
+		return nil, fmt.Errorf("-workspace: no go.work file")
+	}
+
+	out, err := goCmd(ctx, dir, "work", "edit", "-json", gowork)
+	if err != nil {
+		return nil, err
+	}
+	var work struct {
+		Use []struct {
+			DiskPath string
+		}
+	}
+	if err := json.Unmarshal([]byte(out), &work); err != nil {
+		return nil, fmt.Errorf("%s: %w", gowork, err)
+	}
+	if len(work.Use) == 0 {
+		return nil, fmt.Errorf("%s: no modules in use", gowork)
+	}
+
+	var pats []string
+	for _, u := range work.Use {
+		d := u.DiskPath
+		if !filepath.IsAbs(d) {
+			d = filepath.Join(filepath.Dir(gowork), d)
+		}
+		pats = append(pats, d+string(filepath.Separator)+"...")
+	}
+	return pats, nil
+}
+
+func goCmd(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	cmd.Env = LoadEnv()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go %s: %w: %s", strings.Join(args, " "), err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return string(out), nil
+}